@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"aicvevaluator/internal/chromadb"
+	"aicvevaluator/internal/config"
+)
+
+const usage = `Usage: kb [-collection name] <command> [flags] [args]
+
+Inspect and maintain the evaluation guidelines knowledge base in ChromaDB.
+The collection defaults to CHROMADB_COLLECTION.
+
+Commands:
+  collections                     List collections in the database
+  count                           Print the number of documents
+  list   [-where k=v] [-limit n]  List documents with their source and category
+  show   <id>...                  Print documents in full, with metadata
+  delete <id>... | -where k=v     Delete documents by ID or metadata filter
+  export [-where k=v] [-o file]   Write documents as JSON (stdout by default)
+  drop   -yes                     Delete the whole collection
+`
+
+// whereFlag collects repeated -where key=value flags into a metadata filter
+type whereFlag map[string]interface{}
+
+func (w whereFlag) String() string {
+	return fmt.Sprint(map[string]interface{}(w))
+}
+
+func (w whereFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	w[key] = val
+	return nil
+}
+
+// filter converts the flags into a Chroma where clause; several conditions are combined with $and
+func (w whereFlag) filter() map[string]interface{} {
+	if len(w) <= 1 {
+		return w
+	}
+
+	keys := make([]string, 0, len(w))
+	for key := range w {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	conditions := make([]map[string]interface{}, len(keys))
+	for i, key := range keys {
+		conditions[i] = map[string]interface{}{key: w[key]}
+	}
+	return map[string]interface{}{"$and": conditions}
+}
+
+func main() {
+	collectionFlag := flag.String("collection", "", "Collection to work on (defaults to CHROMADB_COLLECTION)")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command, args := flag.Arg(0), flag.Args()[1:]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	collection := cfg.ChromaDB.Collection
+	if *collectionFlag != "" {
+		collection = *collectionFlag
+	}
+
+	ctx := context.Background()
+	embedder, err := chromadb.NewEmbedder(ctx, chromadb.EmbedderConfig{
+		Provider: cfg.Embedding.Provider,
+		Model:    cfg.Embedding.Model,
+		APIKey:   cfg.Embedding.APIKey,
+		BaseURL:  cfg.Embedding.BaseURL,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+	defer embedder.Close()
+
+	client, err := chromadb.NewClient(cfg.ChromaDBURL, embedder,
+		chromadb.WithTenant(cfg.ChromaDB.Tenant),
+		chromadb.WithDatabase(cfg.ChromaDB.Database),
+		chromadb.WithCollection(collection),
+		chromadb.WithAuthToken(cfg.ChromaDB.AuthHeader, cfg.ChromaDB.AuthToken),
+		chromadb.WithTimeout(cfg.ChromaDB.Timeout),
+	)
+	if err != nil {
+		log.Fatalf("Failed to create ChromaDB client: %v", err)
+	}
+
+	// These commands must work even when the collection was built with another embedder
+	switch command {
+	case "collections":
+		err = listCollections(ctx, client)
+	case "drop":
+		err = drop(ctx, client, args)
+	default:
+		if err := client.InitializeCollection(ctx); err != nil {
+			log.Fatalf("Failed to open collection: %v", err)
+		}
+
+		switch command {
+		case "count":
+			err = count(ctx, client)
+		case "list":
+			err = list(ctx, client, args)
+		case "show":
+			err = show(ctx, client, args)
+		case "delete":
+			err = remove(ctx, client, args)
+		case "export":
+			err = export(ctx, client, args)
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", command, usage)
+			os.Exit(2)
+		}
+	}
+
+	if err != nil {
+		log.Fatalf("%s failed: %v", command, err)
+	}
+}
+
+func listCollections(ctx context.Context, client *chromadb.Client) error {
+	collections, err := client.ListCollections(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tID\tEMBEDDER")
+	for _, c := range collections {
+		fmt.Fprintf(w, "%s\t%s\t%v\n", c.Name, c.ID, c.Metadata["embedder"])
+	}
+	return w.Flush()
+}
+
+func count(ctx context.Context, client *chromadb.Client) error {
+	n, err := client.Count(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Println(n)
+	return nil
+}
+
+func list(ctx context.Context, client *chromadb.Client, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	where := whereFlag{}
+	fs.Var(where, "where", "Metadata filter as key=value; repeatable")
+	limit := fs.Int("limit", 0, "Maximum number of documents (0 for all)")
+	fs.Parse(args)
+
+	docs, err := client.Get(ctx, chromadb.GetOptions{Where: where.filter(), Limit: *limit})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSOURCE\tCATEGORY\tPREVIEW")
+	for _, doc := range docs {
+		preview := strings.Join(strings.Fields(doc.Content), " ")
+		if runes := []rune(preview); len(runes) > 60 {
+			preview = string(runes[:57]) + "..."
+		}
+		fmt.Fprintf(w, "%s\t%v\t%v\t%s\n", doc.ID, orDash(doc.Metadata["source"]), orDash(doc.Metadata["category"]), preview)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d document(s)\n", len(docs))
+	return nil
+}
+
+func show(ctx context.Context, client *chromadb.Client, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("at least one document ID is required")
+	}
+
+	docs, err := client.Get(ctx, chromadb.GetOptions{IDs: args})
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return fmt.Errorf("no documents found")
+	}
+
+	for _, doc := range docs {
+		metadata, _ := json.MarshalIndent(doc.Metadata, "", "  ")
+		fmt.Printf("=== %s ===\nMetadata: %s\n\n%s\n\n", doc.ID, metadata, doc.Content)
+	}
+	return nil
+}
+
+func remove(ctx context.Context, client *chromadb.Client, args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	where := whereFlag{}
+	fs.Var(where, "where", "Metadata filter as key=value; repeatable")
+	fs.Parse(args)
+
+	ids := fs.Args()
+	if len(ids) == 0 && len(where) == 0 {
+		return fmt.Errorf("document IDs or -where is required")
+	}
+
+	// Look the documents up first so the operator sees what was removed
+	docs, err := client.Get(ctx, chromadb.GetOptions{IDs: ids, Where: where.filter(), Include: []string{chromadb.IncludeMetadatas}})
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		fmt.Println("No matching documents")
+		return nil
+	}
+
+	if err := client.Delete(ctx, chromadb.DeleteOptions{IDs: ids, Where: where.filter()}); err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		fmt.Printf("Deleted %s\n", doc.ID)
+	}
+	return nil
+}
+
+func export(ctx context.Context, client *chromadb.Client, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	where := whereFlag{}
+	fs.Var(where, "where", "Metadata filter as key=value; repeatable")
+	output := fs.String("o", "", "Output file (stdout if empty)")
+	fs.Parse(args)
+
+	docs, err := client.Get(ctx, chromadb.GetOptions{Where: where.filter()})
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(docs); err != nil {
+		return fmt.Errorf("failed to write documents: %w", err)
+	}
+
+	if *output != "" {
+		fmt.Printf("Exported %d document(s) to %s\n", len(docs), *output)
+	}
+	return nil
+}
+
+func drop(ctx context.Context, client *chromadb.Client, args []string) error {
+	fs := flag.NewFlagSet("drop", flag.ExitOnError)
+	yes := fs.Bool("yes", false, "Confirm deleting the collection")
+	fs.Parse(args)
+
+	if !*yes {
+		return fmt.Errorf("refusing to delete collection %q without -yes", client.CollectionName())
+	}
+
+	if err := client.DeleteCollection(ctx); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted collection %q; run the seeder to rebuild it\n", client.CollectionName())
+	return nil
+}
+
+func orDash(v interface{}) interface{} {
+	if v == nil {
+		return "-"
+	}
+	return v
+}
@@ -2,23 +2,40 @@ package main
 
 import (
 	"aicvevaluator/internal/chromadb"
+	"aicvevaluator/internal/config"
 	"context"
 	"log"
-	"os"
 )
 
 func main() {
-	chromaURL := os.Getenv("CHROMADB_URL")
-	if chromaURL == "" {
-		chromaURL = "http://localhost:8000"
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	ctx := context.Background()
+	embedder, err := chromadb.NewEmbedder(ctx, chromadb.EmbedderConfig{
+		Provider: cfg.Embedding.Provider,
+		Model:    cfg.Embedding.Model,
+		APIKey:   cfg.Embedding.APIKey,
+		BaseURL:  cfg.Embedding.BaseURL,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
 	}
+	defer embedder.Close()
 
-	client, err := chromadb.NewClient(chromaURL)
+	client, err := chromadb.NewClient(cfg.ChromaDBURL, embedder,
+		chromadb.WithTenant(cfg.ChromaDB.Tenant),
+		chromadb.WithDatabase(cfg.ChromaDB.Database),
+		chromadb.WithCollection(cfg.ChromaDB.Collection),
+		chromadb.WithAuthToken(cfg.ChromaDB.AuthHeader, cfg.ChromaDB.AuthToken),
+		chromadb.WithTimeout(cfg.ChromaDB.Timeout),
+	)
 	if err != nil {
 		log.Fatalf("Failed to create ChromaDB client: %v", err)
 	}
 
-	ctx := context.Background()
 	err = client.InitializeCollection(ctx)
 	if err != nil {
 		log.Fatalf("Failed to initialize collection: %v", err)
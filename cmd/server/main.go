@@ -3,6 +3,11 @@ package main
 import (
 	"context"
 	"log"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	database "aicvevaluator/database/migration"
 	"aicvevaluator/internal/ai"
@@ -11,7 +16,9 @@ import (
 	"aicvevaluator/internal/handler"
 	"aicvevaluator/internal/repository"
 	"aicvevaluator/internal/service"
+	"aicvevaluator/internal/storage"
 	"aicvevaluator/internal/util"
+	"aicvevaluator/internal/vectorstore"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -24,7 +31,8 @@ func main() {
 	}
 
 	// 2. Initialize Database Connection with Migration
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 	database.InitPostgresql(ctx, cfg)
 
 	// 3. Get Database Connection
@@ -38,46 +46,231 @@ func main() {
 	// 3. Initialize AI Components
 	fileReader := util.NewFileReader()
 
-	// Initialize ChromaDB
-	chromaClient, err := chromadb.NewClient(cfg.ChromaDBURL)
+	files, err := storage.New(ctx, storage.Config{
+		Backend: cfg.Storage.Backend,
+		Root:    cfg.Storage.LocalRoot,
+		S3: storage.S3Config{
+			Endpoint:     cfg.Storage.S3Endpoint,
+			Region:       cfg.Storage.S3Region,
+			Bucket:       cfg.Storage.S3Bucket,
+			AccessKey:    cfg.Storage.S3AccessKey,
+			SecretKey:    cfg.Storage.S3SecretKey,
+			PathStyle:    cfg.Storage.S3PathStyle,
+			CreateBucket: cfg.Storage.S3CreateBucket,
+		},
+	})
 	if err != nil {
-		log.Printf("Warning: Failed to connect to ChromaDB: %v", err)
-		log.Printf("Continuing without ChromaDB support...")
-		chromaClient = nil
-	} else {
-		err = chromaClient.InitializeCollection(ctx)
-		if err != nil {
-			log.Printf("Warning: Failed to initialize ChromaDB collection: %v", err)
-		}
+		log.Fatalf("Failed to initialize %s file storage: %v", cfg.Storage.Backend, err)
 	}
 
-	// Initialize Gemini AI
-	geminiClient, err := ai.NewGeminiClient(ctx, cfg.GeminiAPIKey)
+	// Initialize the knowledge base
+	embedder, err := chromadb.NewEmbedder(ctx, chromadb.EmbedderConfig{
+		Provider: cfg.Embedding.Provider,
+		Model:    cfg.Embedding.Model,
+		APIKey:   cfg.Embedding.APIKey,
+		BaseURL:  cfg.Embedding.BaseURL,
+	})
 	if err != nil {
-		log.Fatalf("Failed to initialize Gemini client: %v", err)
+		log.Fatalf("Failed to initialize embedder %q: %v", cfg.Embedding.Provider, err)
 	}
-	defer geminiClient.Close()
+	defer embedder.Close()
+
+	vectorStore, chromaClient := newVectorStore(ctx, cfg, embedder)
+
+	// Initialize LLM provider
+	llmProvider, err := ai.NewLLMProvider(ctx, ai.ProviderConfig{
+		Provider: cfg.LLM.Provider,
+		Model:    cfg.LLM.Model,
+		APIKey:   cfg.LLM.APIKey,
+		BaseURL:  cfg.LLM.BaseURL,
+
+		FakeScript: cfg.LLM.FakeScript,
+		FakeFaults: cfg.LLM.FakeFaults,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize LLM provider %q: %v", cfg.LLM.Provider, err)
+	}
+	defer llmProvider.Close()
+
+	documentRepo := repository.NewDocumentRepository(db)
+	documentService := service.NewDocumentService(documentRepo, files)
+
+	redactor := newRedactor(cfg)
 
 	// Initialize AI Pipeline
-	aiPipeline := ai.NewPipeline(fileReader, chromaClient, geminiClient)
+	aiPipeline := ai.NewPipeline(files, fileReader, documentService, redactor, vectorStore, llmProvider, ai.RetryPolicy{
+		MaxAttempts: cfg.LLM.MaxAttempts,
+		BaseDelay:   cfg.LLM.RetryBaseDelay,
+		MaxDelay:    cfg.LLM.RetryMaxDelay,
+	}, ai.RetrievalPolicy{
+		TopK:        cfg.Retrieval.TopK,
+		MaxDistance: cfg.Retrieval.MaxDistance,
+	})
 
 	// 4. Initialize Layers (Dependency Injection)
 	evaluationRepo := repository.NewEvaluationRepository(db)
-	evaluationService := service.NewEvaluationService(evaluationRepo, aiPipeline)
-	evaluationHandler := handler.NewEvaluationHandler(evaluationService)
+	jobDescriptionRepo := repository.NewJobDescriptionRepository(db)
+	worker := service.NewWorker(evaluationRepo, aiPipeline, service.WorkerConfig{
+		Concurrency:       cfg.Queue.Workers,
+		QueueDepth:        cfg.Queue.MaxDepth,
+		PollInterval:      cfg.Queue.PollInterval,
+		LeaseDuration:     cfg.Queue.LeaseDuration,
+		HeartbeatInterval: cfg.Queue.HeartbeatInterval,
+		MaxAttempts:       cfg.Queue.MaxAttempts,
+	})
+	evaluationService := service.NewEvaluationService(evaluationRepo, jobDescriptionRepo, worker, cfg.ChromaDB.TrackCollections)
+	uploadValidator := service.NewUploadValidator(fileReader, service.UploadLimits{
+		MaxFileSize:   cfg.Upload.MaxFileSize,
+		MaxPages:      cfg.Upload.MaxPages,
+		MinTextLength: cfg.Upload.MinTextLength,
+	})
+	retentionRepo := repository.NewRetentionRepository(db)
+	retentionService := service.NewRetentionService(retentionRepo, files, service.RetentionPolicy{
+		Files:         cfg.Retention.Files,
+		Results:       cfg.Retention.Results,
+		PurgeInterval: cfg.Retention.PurgeInterval,
+	})
+	evaluationHandler := handler.NewEvaluationHandler(evaluationService, uploadValidator, documentService, retentionService)
+	jobDescriptionService := service.NewJobDescriptionService(jobDescriptionRepo)
+	jobDescriptionHandler := handler.NewJobDescriptionHandler(jobDescriptionService)
+	healthHandler := handler.NewHealthHandler(chromaClient)
 
 	// 5. Setup Fiber App and Routes
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		// Leave room for both files and the form fields, so oversized files get a structured error
+		BodyLimit: int(2*cfg.Upload.MaxFileSize) + 1<<20,
+	})
+
+	app.Get("/health", healthHandler.Health)
 
 	api := app.Group("/api/v1") // Grouping routes
 	api.Post("/evaluate", evaluationHandler.Evaluate)
 	api.Get("/result/:id", evaluationHandler.GetResult)
+	api.Post("/evaluations/:id/cancel", evaluationHandler.Cancel)
+	api.Delete("/evaluations/:id", evaluationHandler.Delete)
+
+	api.Post("/job-descriptions", jobDescriptionHandler.Create)
+	api.Get("/job-descriptions", jobDescriptionHandler.List)
+	api.Get("/job-descriptions/:id", jobDescriptionHandler.Get)
+	api.Put("/job-descriptions/:id", jobDescriptionHandler.Update)
+	api.Delete("/job-descriptions/:id", jobDescriptionHandler.Delete)
 	// TODO: Add /upload endpoint later
 
-	// 6. Start Server
-	log.Printf("Server starting on port %s", cfg.AppPort)
-	err = app.Listen(cfg.AppPort)
+	// 6. Start Queue Worker (resumes any jobs left over from a previous run) and retention janitor
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		worker.Run(ctx)
+	}()
+
+	// Purge candidate data past its retention period
+	go func() {
+		defer wg.Done()
+		retentionService.Run(ctx)
+	}()
+
+	// 7. Start Server
+	go func() {
+		log.Printf("Server starting on port %s", cfg.AppPort)
+		if err := app.Listen(cfg.AppPort); err != nil {
+			log.Fatalf("server failed to start: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	if err := app.ShutdownWithTimeout(10 * time.Second); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+	wg.Wait()
+}
+
+// newRedactor builds the PII redactor configured in cfg; nil means redaction is disabled
+func newRedactor(cfg *config.Config) *ai.Redactor {
+	if len(cfg.Redaction.Types) == 0 {
+		log.Println("Warning: PII redaction is disabled; CVs are sent to the LLM provider as uploaded")
+		return nil
+	}
+
+	policy := ai.RedactionPolicy{}
+	for _, t := range cfg.Redaction.Types {
+		policy.Types = append(policy.Types, ai.PIIType(t))
+	}
+	if cfg.Redaction.NamesFile != "" {
+		names, err := ai.ReadNameList(cfg.Redaction.NamesFile)
+		if err != nil {
+			log.Fatalf("Failed to read PII_NAMES_FILE: %v", err)
+		}
+		policy.Names = names
+	}
+
+	redactor, err := ai.NewRedactor(policy)
 	if err != nil {
-		log.Fatalf("server failed to start: %v", err)
+		log.Fatalf("Invalid PII_REDACTION: %v", err)
+	}
+	log.Printf("PII redaction enabled for %s", strings.Join(cfg.Redaction.Types, ", "))
+	return redactor
+}
+
+// newVectorStore builds the knowledge base selected in cfg. ChromaDB is backed by the embedded
+// store when guidelines are available locally; nil means retrieval uses the default guidelines.
+// The ChromaDB client is also returned, if one was created, so its health can be reported.
+func newVectorStore(ctx context.Context, cfg *config.Config, embedder chromadb.Embedder) (ai.VectorStore, *chromadb.Client) {
+	var embedded *vectorstore.EmbeddedStore
+	if cfg.Retrieval.GuidelinesDir != "" {
+		store, err := vectorstore.NewEmbeddedStore(ctx, embedder, vectorstore.EmbeddedConfig{
+			Dir:        cfg.Retrieval.GuidelinesDir,
+			Collection: cfg.ChromaDB.Collection,
+			CachePath:  cfg.Retrieval.CachePath,
+		})
+		if err != nil {
+			log.Printf("Warning: Failed to load embedded knowledge base from %s: %v", cfg.Retrieval.GuidelinesDir, err)
+		} else {
+			embedded = store
+			log.Printf("Embedded knowledge base loaded with %d chunks from %s", store.Count(), cfg.Retrieval.GuidelinesDir)
+		}
+	}
+
+	if cfg.Retrieval.Store == "embedded" {
+		if embedded == nil {
+			log.Fatalf("VECTOR_STORE=embedded requires a readable GUIDELINES_DIR")
+		}
+		return embedded, nil
+	}
+
+	chromaClient, err := chromadb.NewClient(cfg.ChromaDBURL, embedder,
+		chromadb.WithTenant(cfg.ChromaDB.Tenant),
+		chromadb.WithDatabase(cfg.ChromaDB.Database),
+		chromadb.WithCollection(cfg.ChromaDB.Collection),
+		chromadb.WithAuthToken(cfg.ChromaDB.AuthHeader, cfg.ChromaDB.AuthToken),
+		chromadb.WithTimeout(cfg.ChromaDB.Timeout),
+		chromadb.WithRetry(chromadb.RetryConfig{
+			MaxAttempts: cfg.ChromaDB.MaxAttempts,
+			BaseDelay:   cfg.ChromaDB.RetryBaseDelay,
+			MaxDelay:    cfg.ChromaDB.RetryMaxDelay,
+		}),
+		chromadb.WithCircuitBreaker(cfg.ChromaDB.BreakerThreshold, cfg.ChromaDB.BreakerCooldown),
+	)
+	if err != nil {
+		log.Printf("Warning: Failed to create ChromaDB client: %v", err)
+		if embedded == nil {
+			return nil, nil
+		}
+		return embedded, nil
+	}
+
+	// A collection that cannot be initialized now is initialized by the first query after
+	// ChromaDB recovers; until then the embedded store or default guidelines are used
+	if err := chromaClient.InitializeCollection(ctx); err != nil {
+		log.Printf("Warning: ChromaDB unavailable, retrying on each retrieval: %v", err)
+	}
+
+	if embedded != nil {
+		log.Printf("ChromaDB retrieval using embedder %s, with the embedded knowledge base as fallback", chromaClient.EmbedderIdentity())
+		return ai.NewFallbackStore(chromaClient, embedded), chromaClient
 	}
+	log.Printf("ChromaDB retrieval using embedder %s", chromaClient.EmbedderIdentity())
+	return chromaClient, chromaClient
 }
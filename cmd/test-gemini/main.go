@@ -25,7 +25,7 @@ func main() {
 	ctx := context.Background()
 
 	// Test Gemini client initialization
-	client, err := ai.NewGeminiClient(ctx, apiKey)
+	client, err := ai.NewGeminiClient(ctx, apiKey, os.Getenv("LLM_MODEL"))
 	if err != nil {
 		log.Fatalf("Failed to create Gemini client: %v", err)
 	}
@@ -35,7 +35,7 @@ func main() {
 	testCV := "Software Engineer with 3 years experience in Go, Python, and React."
 	testReport := "Built a REST API using Go with PostgreSQL database and Docker deployment."
 
-	result, err := client.Stage1Analysis(ctx, testCV, testReport)
+	result, err := client.Stage1Analysis(ctx, ai.Candidate{CV: testCV, Report: testReport}, nil)
 	if err != nil {
 		log.Fatalf("Failed to test Gemini API: %v", err)
 	}
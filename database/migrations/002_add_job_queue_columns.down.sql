@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_evaluations_queue;
+
+ALTER TABLE evaluations
+    DROP COLUMN IF EXISTS lease_expires_at,
+    DROP COLUMN IF EXISTS lease_owner,
+    DROP COLUMN IF EXISTS attempts;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE evaluations
+    ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN lease_owner TEXT,
+    ADD COLUMN lease_expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_evaluations_queue ON evaluations (status, created_at);
\ No newline at end of file
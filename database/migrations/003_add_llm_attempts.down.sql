@@ -0,0 +1,2 @@
+ALTER TABLE evaluations
+    DROP COLUMN IF EXISTS llm_attempts;
\ No newline at end of file
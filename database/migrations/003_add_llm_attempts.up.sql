@@ -0,0 +1,2 @@
+ALTER TABLE evaluations
+    ADD COLUMN llm_attempts JSONB NOT NULL DEFAULT '[]'::jsonb;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE evaluations
+    DROP COLUMN IF EXISTS failed_stage,
+    DROP COLUMN IF EXISTS error_message,
+    DROP COLUMN IF EXISTS error_code;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE evaluations
+    ADD COLUMN error_code VARCHAR(50),
+    ADD COLUMN error_message TEXT,
+    ADD COLUMN failed_stage VARCHAR(20);
\ No newline at end of file
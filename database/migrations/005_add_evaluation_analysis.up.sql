@@ -0,0 +1,2 @@
+ALTER TABLE evaluations
+    ADD COLUMN analysis JSONB;
\ No newline at end of file
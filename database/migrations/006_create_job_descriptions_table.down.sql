@@ -0,0 +1,5 @@
+ALTER TABLE evaluations
+    DROP COLUMN IF EXISTS job_description,
+    DROP COLUMN IF EXISTS job_description_id;
+
+DROP TABLE IF EXISTS job_descriptions;
\ No newline at end of file
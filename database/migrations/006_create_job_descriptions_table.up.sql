@@ -0,0 +1,11 @@
+CREATE TABLE job_descriptions (
+    id UUID PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE evaluations
+    ADD COLUMN job_description_id UUID REFERENCES job_descriptions (id) ON DELETE SET NULL,
+    ADD COLUMN job_description TEXT;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE evaluations
+    DROP COLUMN IF EXISTS collection,
+    DROP COLUMN IF EXISTS track;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE evaluations
+    ADD COLUMN track TEXT,
+    ADD COLUMN collection TEXT;
\ No newline at end of file
@@ -0,0 +1,6 @@
+UPDATE evaluations
+SET cv_key = 'uploads/' || cv_key,
+    report_key = 'uploads/' || report_key;
+
+ALTER TABLE evaluations RENAME COLUMN cv_key TO cv_path;
+ALTER TABLE evaluations RENAME COLUMN report_key TO report_path;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE evaluations RENAME COLUMN cv_path TO cv_key;
+ALTER TABLE evaluations RENAME COLUMN report_path TO report_key;
+
+-- Files used to be stored by path relative to the working directory; keys are relative to the storage root
+UPDATE evaluations
+SET cv_key = regexp_replace(cv_key, '^uploads/', ''),
+    report_key = regexp_replace(report_key, '^uploads/', '');
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE evaluations
+    DROP COLUMN IF EXISTS cv_document_id,
+    DROP COLUMN IF EXISTS report_document_id;
+
+DROP TABLE IF EXISTS documents;
\ No newline at end of file
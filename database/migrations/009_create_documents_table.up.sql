@@ -0,0 +1,13 @@
+CREATE TABLE documents (
+    id UUID PRIMARY KEY,
+    sha256 TEXT NOT NULL UNIQUE,
+    storage_key TEXT NOT NULL UNIQUE,
+    size BIGINT NOT NULL,
+    mime_type TEXT NOT NULL,
+    extracted JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE evaluations
+    ADD COLUMN cv_document_id UUID REFERENCES documents (id),
+    ADD COLUMN report_document_id UUID REFERENCES documents (id);
\ No newline at end of file
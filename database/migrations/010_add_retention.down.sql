@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS deletion_audit_log;
+
+ALTER TABLE documents
+    DROP COLUMN IF EXISTS last_used_at;
+
+DROP INDEX IF EXISTS idx_evaluations_cv_document;
+DROP INDEX IF EXISTS idx_evaluations_report_document;
+
+ALTER TABLE evaluations
+    DROP COLUMN IF EXISTS files_deleted_at,
+    DROP COLUMN IF EXISTS redacted_at;
\ No newline at end of file
@@ -0,0 +1,19 @@
+ALTER TABLE evaluations
+    ADD COLUMN files_deleted_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN redacted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_evaluations_cv_document ON evaluations (cv_document_id);
+CREATE INDEX idx_evaluations_report_document ON evaluations (report_document_id);
+
+ALTER TABLE documents
+    ADD COLUMN last_used_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+-- Evaluations and documents are deleted, so the log keeps their IDs without foreign keys
+CREATE TABLE deletion_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    evaluation_id UUID,
+    document_id UUID,
+    action VARCHAR(30) NOT NULL,
+    reason VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
\ No newline at end of file
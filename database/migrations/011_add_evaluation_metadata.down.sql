@@ -0,0 +1,2 @@
+ALTER TABLE evaluations
+    DROP COLUMN IF EXISTS metadata;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE evaluations
+    ADD COLUMN metadata JSONB;
\ No newline at end of file
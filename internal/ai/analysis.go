@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Stage1Analysis is the structured output of the Stage 1 LLM call
+type Stage1Analysis struct {
+	CVSkills                 []string `json:"cv_skills"`
+	CVExperienceLevel        string   `json:"cv_experience_level" enum:"junior,mid,senior"`
+	ProjectComplexity        string   `json:"project_complexity" enum:"low,medium,high"`
+	ProjectTechnologies      []string `json:"project_technologies"`
+	SkillAlignment           string   `json:"skill_alignment" enum:"poor,fair,good,excellent"`
+	AreasForDeeperEvaluation []string `json:"areas_for_deeper_evaluation"`
+}
+
+// Validate checks that enumerated fields hold one of their allowed values
+func (a *Stage1Analysis) Validate() error {
+	checks := []struct {
+		field, value string
+		allowed      []string
+	}{
+		{"cv_experience_level", a.CVExperienceLevel, []string{"junior", "mid", "senior"}},
+		{"project_complexity", a.ProjectComplexity, []string{"low", "medium", "high"}},
+		{"skill_alignment", a.SkillAlignment, []string{"poor", "fair", "good", "excellent"}},
+	}
+
+	for _, c := range checks {
+		if !slices.Contains(c.allowed, c.value) {
+			return fmt.Errorf("%s must be one of %s, got %q", c.field, strings.Join(c.allowed, "/"), c.value)
+		}
+	}
+	return nil
+}
+
+// Validate checks that scores are within range and every feedback field is filled in
+func (r *EvaluationResult) Validate() error {
+	if r.CVMatchRate < 0 || r.CVMatchRate > 1 {
+		return fmt.Errorf("cv_match_rate must be between 0.0 and 1.0, got %v", r.CVMatchRate)
+	}
+	if r.ProjectScore < 0 || r.ProjectScore > 10 {
+		return fmt.Errorf("project_score must be between 0.0 and 10.0, got %v", r.ProjectScore)
+	}
+
+	texts := []struct{ field, value string }{
+		{"cv_feedback", r.CVFeedback},
+		{"project_feedback", r.ProjectFeedback},
+		{"overall_summary", r.OverallSummary},
+	}
+	for _, t := range texts {
+		if strings.TrimSpace(t.value) == "" {
+			return fmt.Errorf("%s must not be empty", t.field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/googleapi"
+)
+
+// Error categories returned by LLM calls. Callers should use errors.Is to check them.
+var (
+	ErrQuotaExceeded = errors.New("LLM quota exceeded")
+	ErrModelNotFound = errors.New("LLM model not found")
+	ErrSafetyBlocked = errors.New("LLM response blocked by safety filters")
+	ErrUnavailable   = errors.New("LLM service unavailable")
+	ErrEmptyResponse = errors.New("empty LLM response")
+)
+
+// IsRetryable reports whether err is a transient failure worth retrying
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	return errors.Is(err, ErrQuotaExceeded) ||
+		errors.Is(err, ErrUnavailable) ||
+		errors.Is(err, ErrEmptyResponse)
+}
+
+// classifyGeminiError maps a Gemini SDK error onto one of the error categories above
+func classifyGeminiError(err error) error {
+	var blocked *genai.BlockedError
+	if errors.As(err, &blocked) {
+		return fmt.Errorf("%w: %w", ErrSafetyBlocked, err)
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == http.StatusTooManyRequests:
+			return fmt.Errorf("%w: %w", ErrQuotaExceeded, err)
+		case apiErr.Code == http.StatusNotFound:
+			return fmt.Errorf("%w: %w", ErrModelNotFound, err)
+		case apiErr.Code >= http.StatusInternalServerError:
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	// Fall back to message matching for quota errors that lost their type along the way. A
+	// missing model is only recognised from the API error, since it is a permanent failure.
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "quota") || strings.Contains(msg, "429") {
+		return fmt.Errorf("%w: %w", ErrQuotaExceeded, err)
+	}
+
+	return err
+}
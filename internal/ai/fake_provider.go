@@ -0,0 +1,262 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ProviderFake selects the scripted provider, used for offline runs and tests
+const ProviderFake = "fake"
+
+// Stage names used by the fake provider for scripting and fault injection
+const (
+	FakeStage1 = "stage1"
+	FakeStage2 = "stage2"
+	FakeJSON   = "json"
+)
+
+// Fault kinds understood by the fake provider
+const (
+	FaultQuota         = "quota"
+	FaultSafety        = "safety"
+	FaultMalformedJSON = "malformed_json"
+	FaultUnavailable   = "unavailable"
+	FaultModelNotFound = "model_not_found"
+)
+
+const fakeStage1Response = `{
+  "cv_skills": ["Go", "PostgreSQL", "Docker", "REST APIs"],
+  "cv_experience_level": "mid",
+  "project_complexity": "medium",
+  "project_technologies": ["Go", "Fiber", "PostgreSQL", "ChromaDB"],
+  "skill_alignment": "good",
+  "areas_for_deeper_evaluation": ["testing strategy", "error handling"]
+}`
+
+const fakeStage2Response = `{
+  "cv_match_rate": 0.75,
+  "cv_feedback": "Solid backend experience with Go and PostgreSQL. Add measurable achievements to strengthen the CV.",
+  "project_score": 7.5,
+  "project_feedback": "Clean layered architecture and working endpoints. Test coverage and retry handling could be improved.",
+  "overall_summary": "A capable mid-level backend engineer who is a good fit for the role."
+}`
+
+const fakeMalformedJSON = `{"cv_match_rate": 0.75, "cv_feedback": "truncated`
+
+// FakeResponse is a scripted reply. Fault, if set, is injected instead of returning Text.
+type FakeResponse struct {
+	Text  string `json:"text,omitempty"`
+	Fault string `json:"fault,omitempty"`
+}
+
+// FakeCall records a single call made to the fake provider
+type FakeCall struct {
+	Stage       string
+	Fingerprint string
+}
+
+// FakeProvider is a deterministic LLMProvider that returns canned responses.
+// Responses can be scripted per prompt fingerprint and faults can be injected per stage.
+type FakeProvider struct {
+	mu       sync.Mutex
+	scripted map[string]FakeResponse
+	faults   map[string][]string
+	calls    []FakeCall
+}
+
+// NewFakeProvider creates a fake provider that answers every stage with a valid canned response
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		scripted: make(map[string]FakeResponse),
+		faults:   make(map[string][]string),
+	}
+}
+
+// NewFakeProviderFromConfig creates a fake provider, loading a script file and fault spec when given.
+// The script file is a JSON object mapping prompt fingerprints to FakeResponse values.
+// The fault spec is a comma-separated list of stage=fault[*times], e.g. "stage1=quota*2,stage2=malformed_json".
+func NewFakeProviderFromConfig(scriptPath, faultSpec string) (*FakeProvider, error) {
+	f := NewFakeProvider()
+
+	if scriptPath != "" {
+		data, err := os.ReadFile(scriptPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fake LLM script: %w", err)
+		}
+
+		var script map[string]FakeResponse
+		if err := json.Unmarshal(data, &script); err != nil {
+			return nil, fmt.Errorf("failed to parse fake LLM script: %w", err)
+		}
+		for fingerprint, resp := range script {
+			if resp.Fault != "" && !validFault(resp.Fault) {
+				return nil, fmt.Errorf("unknown fake LLM fault %q for prompt %s", resp.Fault, fingerprint)
+			}
+			f.Script(fingerprint, resp)
+		}
+	}
+
+	for _, entry := range strings.Split(faultSpec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		stage, fault, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid fake LLM fault %q: expected stage=fault[*times]", entry)
+		}
+
+		switch stage {
+		case FakeStage1, FakeStage2, FakeJSON:
+		default:
+			return nil, fmt.Errorf("unknown fake LLM stage %q in %q", stage, entry)
+		}
+
+		fault, count, hasCount := strings.Cut(fault, "*")
+		times := 1
+		if hasCount {
+			n, err := strconv.Atoi(count)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid fake LLM fault count in %q", entry)
+			}
+			times = n
+		}
+
+		if !validFault(fault) {
+			return nil, fmt.Errorf("unknown fake LLM fault %q in %q", fault, entry)
+		}
+
+		f.InjectFault(stage, fault, times)
+	}
+
+	return f, nil
+}
+
+// validFault reports whether fault is one of the fault kinds the fake provider can inject
+func validFault(fault string) bool {
+	switch fault {
+	case FaultQuota, FaultSafety, FaultMalformedJSON, FaultUnavailable, FaultModelNotFound:
+		return true
+	}
+	return false
+}
+
+// Fingerprint returns the key used to script a response for prompt
+func Fingerprint(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Script sets the response returned for the prompt with the given fingerprint
+func (f *FakeProvider) Script(fingerprint string, resp FakeResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.scripted[fingerprint] = resp
+}
+
+// InjectFault makes the next times calls to stage fail with the given fault
+func (f *FakeProvider) InjectFault(stage, fault string, times int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := 0; i < times; i++ {
+		f.faults[stage] = append(f.faults[stage], fault)
+	}
+}
+
+// Calls returns the calls made so far, in order
+func (f *FakeProvider) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]FakeCall(nil), f.calls...)
+}
+
+// Close is a no-op
+func (f *FakeProvider) Close() error {
+	return nil
+}
+
+// Stage1Analysis returns the scripted or canned Stage 1 analysis
+func (f *FakeProvider) Stage1Analysis(ctx context.Context, candidate Candidate, chromaContext []string) (string, error) {
+	return f.respond(ctx, FakeStage1, buildStage1Prompt(candidate, chromaContext), fakeStage1Response)
+}
+
+// Stage2Evaluation returns the scripted or canned Stage 2 evaluation
+func (f *FakeProvider) Stage2Evaluation(ctx context.Context, candidate Candidate, analysis *Stage1Analysis, chromaContext []string) (string, error) {
+	return f.respond(ctx, FakeStage2, buildStage2Prompt(candidate, analysis, chromaContext), fakeStage2Response)
+}
+
+// GenerateJSON strictly decodes the scripted response for prompt into out
+func (f *FakeProvider) GenerateJSON(ctx context.Context, prompt string, out any) error {
+	// Fall back to the canned stage output matching the requested type, so repair round trips succeed
+	fallback := "{}"
+	switch out.(type) {
+	case *Stage1Analysis:
+		fallback = fakeStage1Response
+	case *EvaluationResult:
+		fallback = fakeStage2Response
+	}
+
+	text, err := f.respond(ctx, FakeJSON, prompt, fallback)
+	if err != nil {
+		return err
+	}
+
+	return decodeStrict(text, out)
+}
+
+// respond records the call and returns the injected fault, scripted response or fallback, in that order
+func (f *FakeProvider) respond(ctx context.Context, stage, prompt, fallback string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	fingerprint := Fingerprint(prompt)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, FakeCall{Stage: stage, Fingerprint: fingerprint})
+
+	var fault string
+	if pending := f.faults[stage]; len(pending) > 0 {
+		fault = pending[0]
+		f.faults[stage] = pending[1:]
+	}
+
+	resp, scripted := f.scripted[fingerprint]
+	f.mu.Unlock()
+
+	if fault == "" && scripted {
+		fault = resp.Fault
+	}
+
+	switch fault {
+	case "":
+	case FaultQuota:
+		return "", fmt.Errorf("%w: fake quota exhausted", ErrQuotaExceeded)
+	case FaultSafety:
+		return "", ErrSafetyBlocked
+	case FaultUnavailable:
+		return "", fmt.Errorf("%w: fake service unavailable", ErrUnavailable)
+	case FaultModelNotFound:
+		return "", fmt.Errorf("%w: fake model missing", ErrModelNotFound)
+	case FaultMalformedJSON:
+		return fakeMalformedJSON, nil
+	default:
+		return "", fmt.Errorf("unknown fake LLM fault: %s", fault)
+	}
+
+	if scripted && resp.Text != "" {
+		return resp.Text, nil
+	}
+	return fallback, nil
+}
@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewFakeProviderFromConfigFaults(t *testing.T) {
+	f, err := NewFakeProviderFromConfig("", " stage1=quota*2, stage2=model_not_found ,json=malformed_json")
+	if err != nil {
+		t.Fatalf("NewFakeProviderFromConfig() error = %v", err)
+	}
+	want := map[string][]string{
+		FakeStage1: {FaultQuota, FaultQuota},
+		FakeStage2: {FaultModelNotFound},
+		FakeJSON:   {FaultMalformedJSON},
+	}
+	for stage, faults := range want {
+		if got := f.faults[stage]; len(got) != len(faults) || got[0] != faults[0] {
+			t.Errorf("faults[%s] = %v, want %v", stage, got, faults)
+		}
+	}
+
+	for _, spec := range []string{
+		"stage1",                // no fault
+		"stage3=quota",          // unknown stage
+		"Stage1=quota",          // stage names are case-sensitive
+		"stage1=timeout",        // unknown fault
+		"stage1=quota*0",        // count below one
+		"stage1=quota*two",      // count not a number
+		"stage1=quota,stage2=x", // one bad entry fails the spec
+	} {
+		if _, err := NewFakeProviderFromConfig("", spec); err == nil {
+			t.Errorf("NewFakeProviderFromConfig(%q) returned no error", spec)
+		}
+	}
+}
+
+func TestFakeProviderScript(t *testing.T) {
+	ctx := context.Background()
+	candidate := Candidate{CV: "Go developer", Report: "A queue service"}
+	prompt := buildStage1Prompt(candidate, nil)
+
+	path := filepath.Join(t.TempDir(), "script.json")
+	script := `{"` + Fingerprint(prompt) + `": {"fault": "unavailable"}}`
+	if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewFakeProviderFromConfig(path, "")
+	if err != nil {
+		t.Fatalf("NewFakeProviderFromConfig() error = %v", err)
+	}
+	if _, err := f.Stage1Analysis(ctx, candidate, nil); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("scripted Stage1Analysis() error = %v, want ErrUnavailable", err)
+	}
+
+	// Unknown scripted faults are rejected at load, like those in the fault spec
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"`+Fingerprint(prompt)+`": {"fault": "Unavailable"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFakeProviderFromConfig(bad, ""); err == nil {
+		t.Error("NewFakeProviderFromConfig() accepted a script with an unknown fault")
+	}
+
+	// Other prompts get the canned response
+	text, err := f.Stage1Analysis(ctx, Candidate{CV: "Python developer"}, nil)
+	if err != nil || text != fakeStage1Response {
+		t.Errorf("Stage1Analysis() = %q, %v; want the canned response", text, err)
+	}
+
+	calls := f.Calls()
+	if len(calls) != 2 || calls[0].Fingerprint != Fingerprint(prompt) || calls[1].Stage != FakeStage1 {
+		t.Errorf("Calls() = %+v", calls)
+	}
+}
@@ -3,31 +3,56 @@ package ai
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
 )
 
+// DefaultGeminiModel is used when no model name is configured
+const DefaultGeminiModel = "gemini-2.5-pro"
+
 // GeminiClient wraps Gemini API operations
 type GeminiClient struct {
-	client *genai.Client
-	model  *genai.GenerativeModel
+	client      *genai.Client
+	modelName   string
+	stage1Model *genai.GenerativeModel
+	stage2Model *genai.GenerativeModel
 }
 
 // NewGeminiClient creates a new Gemini client
-func NewGeminiClient(ctx context.Context, apiKey string) (*GeminiClient, error) {
+func NewGeminiClient(ctx context.Context, apiKey, modelName string) (*GeminiClient, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("Gemini API key is required")
 	}
+	if modelName == "" {
+		modelName = DefaultGeminiModel
+	}
 
 	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
 	}
 
-	// Use the correct model name for Google AI Studio API
-	model := client.GenerativeModel("gemini-2.5-pro")
+	return &GeminiClient{
+		client:    client,
+		modelName: modelName,
+		// Stage outputs are constrained by response schemas so they decode without guesswork
+		stage1Model: newGeminiJSONModel(client, modelName, Stage1Analysis{}),
+		stage2Model: newGeminiJSONModel(client, modelName, EvaluationResult{}),
+	}, nil
+}
+
+// newGeminiJSONModel configures a model that must answer with JSON matching the shape of v
+func newGeminiJSONModel(client *genai.Client, modelName string, v any) *genai.GenerativeModel {
+	model := newGeminiModel(client, modelName)
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = schemaFor(v)
+	return model
+}
+
+// newGeminiModel configures a generative model with the evaluator's generation settings
+func newGeminiModel(client *genai.Client, modelName string) *genai.GenerativeModel {
+	model := client.GenerativeModel(modelName)
 	model.SetTemperature(0.1) // Lower temperature for more consistent results
 
 	// Set safety settings to be more permissive for business evaluation content
@@ -50,10 +75,7 @@ func NewGeminiClient(ctx context.Context, apiKey string) (*GeminiClient, error)
 		},
 	}
 
-	return &GeminiClient{
-		client: client,
-		model:  model,
-	}, nil
+	return model
 }
 
 // Close closes the Gemini client
@@ -62,121 +84,58 @@ func (g *GeminiClient) Close() error {
 }
 
 // Stage1Analysis performs initial analysis of CV and project report
-func (g *GeminiClient) Stage1Analysis(ctx context.Context, cvContent, reportContent string) (string, error) {
-	prompt := fmt.Sprintf(`
-You are an expert CV and project evaluator. Analyze the provided CV and project report.
-
-CV Content:
-%s
-
-Project Report Content:
-%s
-
-Please provide an initial analysis focusing on:
-1. Key skills and experience from the CV
-2. Project complexity and technical depth
-3. Alignment between CV skills and project requirements
-4. Initial impressions and areas that need deeper evaluation
-
-Provide a structured analysis in JSON format with the following structure:
-{
-  "cv_skills": ["skill1", "skill2", ...],
-  "cv_experience_level": "junior/mid/senior",
-  "project_complexity": "low/medium/high",
-  "project_technologies": ["tech1", "tech2", ...],
-  "skill_alignment": "poor/fair/good/excellent",
-  "areas_for_deeper_evaluation": ["area1", "area2", ...]
-}
-`, cvContent, reportContent)
+func (g *GeminiClient) Stage1Analysis(ctx context.Context, candidate Candidate, chromaContext []string) (string, error) {
+	prompt := buildStage1Prompt(candidate, chromaContext)
 
-	resp, err := g.model.GenerateContent(ctx, genai.Text(prompt))
+	text, err := g.generateWith(ctx, g.stage1Model, prompt)
 	if err != nil {
-		// Check if it's a quota exceeded error
-		if strings.Contains(err.Error(), "quota") || strings.Contains(err.Error(), "429") {
-			return "", fmt.Errorf("Gemini API quota exceeded. Please check your billing or wait for quota reset: %w", err)
-		}
-		// Check if it's a model not found error
-		if strings.Contains(err.Error(), "404") || strings.Contains(err.Error(), "not found") {
-			return "", fmt.Errorf("Gemini model not available. Try using 'gemini-pro' instead of 'gemini-1.5-pro': %w", err)
-		}
 		return "", fmt.Errorf("failed to generate Stage 1 analysis: %w", err)
 	}
 
-	if len(resp.Candidates) == 0 {
-		return "", fmt.Errorf("no candidates in Gemini API response")
-	}
-
-	if len(resp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no content parts in Gemini API response")
-	}
-
-	// Check if the response was blocked due to safety filters
-	if resp.Candidates[0].FinishReason == genai.FinishReasonSafety {
-		return "", fmt.Errorf("response blocked by Gemini safety filters")
-	}
-
-	return fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0]), nil
+	return text, nil
 }
 
 // Stage2Evaluation performs refined evaluation using context from ChromaDB
-func (g *GeminiClient) Stage2Evaluation(ctx context.Context, stage1Analysis string, chromaContext []string, cvContent, reportContent string) (string, error) {
-	contextStr := strings.Join(chromaContext, "\n\n")
-
-	prompt := fmt.Sprintf(`
-You are an expert CV and project evaluator. Based on the initial analysis and additional context, provide a comprehensive evaluation.
-
-Initial Analysis:
-%s
+func (g *GeminiClient) Stage2Evaluation(ctx context.Context, candidate Candidate, analysis *Stage1Analysis, chromaContext []string) (string, error) {
+	prompt := buildStage2Prompt(candidate, analysis, chromaContext)
 
-Additional Context from Knowledge Base:
-%s
-
-CV Content:
-%s
-
-Project Report Content:
-%s
+	text, err := g.generateWith(ctx, g.stage2Model, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate Stage 2 evaluation: %w", err)
+	}
 
-Based on all this information, provide a comprehensive evaluation in the following JSON format:
-{
-  "cv_match_rate": 0.0-1.0,
-  "cv_feedback": "detailed feedback on CV quality, strengths, and areas for improvement",
-  "project_score": 0.0-10.0,
-  "project_feedback": "detailed feedback on project quality, technical implementation, and documentation",
-  "overall_summary": "comprehensive summary of the candidate's suitability and recommendations"
+	return text, nil
 }
 
-Scoring Guidelines:
-- cv_match_rate: How well the CV matches the project requirements (0.0 = no match, 1.0 = perfect match)
-- project_score: Overall project quality (0-10 scale, where 10 is exceptional)
+// GenerateJSON sends a prompt with a response schema derived from out and strictly decodes the response into out
+func (g *GeminiClient) GenerateJSON(ctx context.Context, prompt string, out any) error {
+	text, err := g.generateWith(ctx, newGeminiJSONModel(g.client, g.modelName, out), prompt)
+	if err != nil {
+		return fmt.Errorf("failed to generate JSON: %w", err)
+	}
 
-Provide constructive, specific feedback that helps the candidate improve.
-`, stage1Analysis, contextStr, cvContent, reportContent)
+	return decodeStrict(text, out)
+}
 
-	resp, err := g.model.GenerateContent(ctx, genai.Text(prompt))
+// generateWith sends a prompt to the given model and returns the text of the first candidate.
+// Errors are classified so callers can tell transient failures from permanent ones.
+func (g *GeminiClient) generateWith(ctx context.Context, model *genai.GenerativeModel, prompt string) (string, error) {
+	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
 	if err != nil {
-		// Check if it's a quota exceeded error
-		if strings.Contains(err.Error(), "quota") || strings.Contains(err.Error(), "429") {
-			return "", fmt.Errorf("Gemini API quota exceeded. Please check your billing or wait for quota reset: %w", err)
-		}
-		// Check if it's a model not found error
-		if strings.Contains(err.Error(), "404") || strings.Contains(err.Error(), "not found") {
-			return "", fmt.Errorf("Gemini model not available. Try using 'gemini-pro' instead of 'gemini-1.5-pro': %w", err)
-		}
-		return "", fmt.Errorf("failed to generate Stage 2 evaluation: %w", err)
+		return "", classifyGeminiError(err)
 	}
 
 	if len(resp.Candidates) == 0 {
-		return "", fmt.Errorf("no candidates in Gemini API response")
-	}
-
-	if len(resp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no content parts in Gemini API response")
+		return "", fmt.Errorf("%w: no candidates in Gemini API response", ErrEmptyResponse)
 	}
 
 	// Check if the response was blocked due to safety filters
 	if resp.Candidates[0].FinishReason == genai.FinishReasonSafety {
-		return "", fmt.Errorf("response blocked by Gemini safety filters")
+		return "", ErrSafetyBlocked
+	}
+
+	if resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("%w: no content parts in Gemini API response", ErrEmptyResponse)
 	}
 
 	return fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0]), nil
@@ -0,0 +1,171 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIClient talks to any OpenAI-compatible /v1/chat/completions endpoint,
+// e.g. OpenAI itself, a local llama.cpp server or vLLM
+type OpenAIClient struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOpenAIClient creates a new OpenAI-compatible client. baseURL should include
+// the version prefix, e.g. http://localhost:8081/v1. apiKey may be empty for local servers.
+func NewOpenAIClient(baseURL, apiKey, model string) (*OpenAIClient, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("OpenAI-compatible base URL is required")
+	}
+	if model == "" {
+		return nil, fmt.Errorf("model name is required for the OpenAI-compatible provider")
+	}
+
+	return &OpenAIClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Minute, // local models can be slow on long CVs
+		},
+	}, nil
+}
+
+// Close is a no-op; the HTTP client holds no resources that need releasing
+func (o *OpenAIClient) Close() error {
+	return nil
+}
+
+// Stage1Analysis performs initial analysis of CV and project report
+func (o *OpenAIClient) Stage1Analysis(ctx context.Context, candidate Candidate, chromaContext []string) (string, error) {
+	text, err := o.chat(ctx, buildStage1Prompt(candidate, chromaContext))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate Stage 1 analysis: %w", err)
+	}
+
+	return text, nil
+}
+
+// Stage2Evaluation performs refined evaluation using context from ChromaDB
+func (o *OpenAIClient) Stage2Evaluation(ctx context.Context, candidate Candidate, analysis *Stage1Analysis, chromaContext []string) (string, error) {
+	text, err := o.chat(ctx, buildStage2Prompt(candidate, analysis, chromaContext))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate Stage 2 evaluation: %w", err)
+	}
+
+	return text, nil
+}
+
+// GenerateJSON sends a prompt in JSON mode and strictly decodes the response into out
+func (o *OpenAIClient) GenerateJSON(ctx context.Context, prompt string, out any) error {
+	text, err := o.chat(ctx, prompt)
+	if err != nil {
+		return fmt.Errorf("failed to generate JSON: %w", err)
+	}
+
+	return decodeStrict(text, out)
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string            `json:"model"`
+	Messages       []chatMessage     `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message      chatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// chat sends a single-turn chat completion request in JSON mode and returns the reply text
+func (o *OpenAIClient) chat(ctx context.Context, prompt string) (string, error) {
+	reqBody := chatRequest{
+		Model:          o.model,
+		Messages:       []chatMessage{{Role: "user", Content: prompt}},
+		Temperature:    0.1, // Lower temperature for more consistent results
+		ResponseFormat: map[string]string{"type": "json_object"},
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && !errors.Is(err, context.Canceled) {
+			return "", fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", classifyHTTPError(resp.StatusCode, string(respBody))
+	}
+
+	var result chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("%w: failed to parse response: %w", ErrInvalidResponse, err)
+	}
+
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("%w: no choices in chat completion response", ErrEmptyResponse)
+	}
+
+	choice := result.Choices[0]
+	if choice.FinishReason == "content_filter" {
+		return "", ErrSafetyBlocked
+	}
+	if strings.TrimSpace(choice.Message.Content) == "" {
+		return "", fmt.Errorf("%w: empty message in chat completion response", ErrEmptyResponse)
+	}
+
+	return choice.Message.Content, nil
+}
+
+// classifyHTTPError maps an HTTP error status onto one of the LLM error categories
+func classifyHTTPError(statusCode int, body string) error {
+	err := fmt.Errorf("HTTP %d - %s", statusCode, body)
+
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", ErrQuotaExceeded, err)
+	case statusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrModelNotFound, err)
+	case statusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
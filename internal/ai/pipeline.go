@@ -1,31 +1,59 @@
 package ai
 
 import (
-	"aicvevaluator/internal/chromadb"
+	"aicvevaluator/internal/storage"
 	"aicvevaluator/internal/util"
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
-	"strings"
+	"unicode/utf8"
 )
 
 // Pipeline orchestrates the AI evaluation process
 type Pipeline struct {
-	fileReader   *util.FileReader
-	chromaClient *chromadb.Client
-	geminiClient *GeminiClient
+	files           storage.Storage
+	fileReader      *util.FileReader
+	cache           DocumentCache // nil disables caching of extracted text
+	redactor        *Redactor     // nil sends documents to the LLM unredacted
+	store           VectorStore   // nil means no knowledge base; default guidelines are used
+	llm             LLMProvider
+	retryPolicy     RetryPolicy
+	retrievalPolicy RetrievalPolicy
 }
 
-// NewPipeline creates a new AI pipeline
-func NewPipeline(fileReader *util.FileReader, chromaClient *chromadb.Client, geminiClient *GeminiClient) *Pipeline {
+// DocumentCache keeps the text extracted from stored files, so identical uploads are only
+// extracted once
+type DocumentCache interface {
+	// CachedDocument returns nil if no text is cached for key
+	CachedDocument(ctx context.Context, key string) (*util.Document, error)
+	CacheDocument(ctx context.Context, key string, doc *util.Document) error
+}
+
+// NewPipeline creates a new AI pipeline. cache, redactor and store may be nil.
+func NewPipeline(files storage.Storage, fileReader *util.FileReader, cache DocumentCache, redactor *Redactor, store VectorStore, llm LLMProvider, retryPolicy RetryPolicy, retrievalPolicy RetrievalPolicy) *Pipeline {
 	return &Pipeline{
-		fileReader:   fileReader,
-		chromaClient: chromaClient,
-		geminiClient: geminiClient,
+		files:           files,
+		fileReader:      fileReader,
+		cache:           cache,
+		redactor:        redactor,
+		store:           store,
+		llm:             llm,
+		retryPolicy:     retryPolicy,
+		retrievalPolicy: retrievalPolicy,
 	}
 }
 
+// EvaluationRequest describes a single evaluation run
+type EvaluationRequest struct {
+	CVKey          string // storage keys of the uploaded files
+	ReportKey      string
+	JobDescription string
+	Collection     string // knowledge base collection to retrieve from; empty for the default
+
+	// OnAttempt, if set, is called after every LLM call attempt
+	OnAttempt AttemptRecorder
+}
+
 // EvaluationResult represents the final evaluation result
 type EvaluationResult struct {
 	CVMatchRate     float64 `json:"cv_match_rate"`
@@ -35,101 +63,176 @@ type EvaluationResult struct {
 	OverallSummary  string  `json:"overall_summary"`
 }
 
+// EvaluationOutput is everything produced by a successful pipeline run
+type EvaluationOutput struct {
+	Result   *EvaluationResult
+	Analysis *Stage1Analysis // intermediate Stage 1 reasoning, kept for reviewers
+
+	// Redactions lists the personal details masked before prompting; nil if redaction is disabled
+	Redactions []RedactionSummary
+
+	// FailedPages lists, by document ("cv" or "report"), the pages left out of the evaluation
+	// because their text could not be extracted
+	FailedPages map[string][]int
+}
+
 // ProcessEvaluation runs the complete AI evaluation pipeline
-func (p *Pipeline) ProcessEvaluation(ctx context.Context, cvPath, reportPath string) (*EvaluationResult, error) {
-	log.Printf("Starting AI pipeline for CV: %s, Report: %s", cvPath, reportPath)
+func (p *Pipeline) ProcessEvaluation(ctx context.Context, req EvaluationRequest) (*EvaluationOutput, error) {
+	log.Printf("Starting AI pipeline for CV: %s, Report: %s", req.CVKey, req.ReportKey)
 
 	// Step 1: Read and normalize file contents
-	cvContent, err := p.fileReader.ReadFile(cvPath)
+	cv, err := p.readDocument(ctx, req.CVKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CV file: %w", err)
+		return nil, stageError(StageRead, fmt.Errorf("failed to read CV file: %w", err))
 	}
 
-	reportContent, err := p.fileReader.ReadFile(reportPath)
+	report, err := p.readDocument(ctx, req.ReportKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read report file: %w", err)
+		return nil, stageError(StageRead, fmt.Errorf("failed to read report file: %w", err))
+	}
+
+	failedPages := make(map[string][]int)
+	for name, doc := range map[string]*util.Document{"cv": cv, "report": report} {
+		if len(doc.FailedPages) > 0 {
+			log.Printf("Warning: could not extract %s pages %v of %d; evaluating the rest", name, doc.FailedPages, doc.Pages)
+			failedPages[name] = doc.FailedPages
+		}
 	}
 
-	log.Printf("Successfully read files - CV: %d chars, Report: %d chars", len(cvContent), len(reportContent))
+	// Mask personal details so they are not sent to the LLM or embedding provider
+	var redaction *Redaction
+	if p.redactor != nil {
+		redaction = p.redactor.Start(cv.Text)
+		cv.Text = redaction.Redact(cv.Text)
+		report.Text = redaction.Redact(report.Text)
+		log.Printf("Redacted %d personal detail(s) from the CV and report", len(redaction.Summary()))
+	}
 
-	// Step 2: Stage 1 Analysis with Gemini
-	stage1Analysis, err := p.geminiClient.Stage1Analysis(ctx, cvContent, reportContent)
-	if err != nil {
-		return nil, fmt.Errorf("failed Stage 1 analysis: %w", err)
+	candidate := Candidate{
+		CV:             cv.Text,
+		CVSections:     util.DetectSections(cv.Text),
+		Report:         report.Text,
+		JobDescription: req.JobDescription,
+		Redacted:       redaction != nil,
 	}
 
-	log.Printf("Stage 1 analysis completed")
+	log.Printf("Successfully read files - CV: %d chars (%s, %d sections), Report: %d chars (%s)",
+		len(cv.Text), cv.Format, len(candidate.CVSections), len(report.Text), report.Format)
 
-	// Step 3: Query ChromaDB for relevant context (optional)
-	var chromaContext []string
-	if p.chromaClient != nil {
-		// Use a simple, relevant query for evaluation guidelines
-		queryText := "CV evaluation guidelines project assessment scoring rubric"
+	// Step 2: Retrieve guidelines relevant to this candidate's CV and report
+	stage1Context := p.retrieve(ctx, req.Collection, StageStage1, stage1Queries(candidate))
 
-		documents, err := p.chromaClient.QueryDocuments(ctx, queryText, 3)
-		if err != nil {
-			log.Printf("ChromaDB query failed, continuing without context: %v", err)
-		} else {
-			// Convert documents to string array
-			for _, doc := range documents {
-				chromaContext = append(chromaContext, doc.Content)
-			}
-			log.Printf("Retrieved %d context documents from ChromaDB", len(chromaContext))
-		}
+	// Step 3: Stage 1 Analysis with the LLM
+	stage1Response, err := withRetry(ctx, p.retryPolicy, StageStage1, req.OnAttempt, func(ctx context.Context) (string, error) {
+		return p.llm.Stage1Analysis(ctx, candidate, stage1Context)
+	})
+	if err != nil {
+		return nil, stageError(StageStage1, fmt.Errorf("failed Stage 1 analysis: %w", err))
 	}
 
+	analysis, err := decodeWithRepair[Stage1Analysis](ctx, p, req, StageStage1, stage1Response)
+	if err != nil {
+		return nil, stageError(StageStage1, fmt.Errorf("failed to parse Stage 1 analysis: %w", err))
+	}
+
+	log.Printf("Stage 1 analysis completed")
+
+	// Step 4: Retrieve guidelines targeted at the skills and technologies Stage 1 found
+	chromaContext := p.retrieve(ctx, req.Collection, StageStage2, stage2Queries(candidate, analysis))
 	if len(chromaContext) == 0 {
 		log.Printf("No ChromaDB context available, using default evaluation guidelines")
-		// Provide default evaluation context when ChromaDB is not available
-		chromaContext = []string{
-			"CV Evaluation: Assess technical skills, experience level, education, and presentation quality. Rate CV match from 0.0-1.0.",
-			"Project Evaluation: Assess code quality, complexity, documentation, and problem-solving approach. Rate project from 0.0-10.0.",
-		}
+		chromaContext = defaultGuidelines
 	}
 
-	// Step 4: Stage 2 Evaluation with context
-	stage2Result, err := p.geminiClient.Stage2Evaluation(ctx, stage1Analysis, chromaContext, cvContent, reportContent)
+	// Step 5: Stage 2 Evaluation with context
+	stage2Result, err := withRetry(ctx, p.retryPolicy, StageStage2, req.OnAttempt, func(ctx context.Context) (string, error) {
+		return p.llm.Stage2Evaluation(ctx, candidate, analysis, chromaContext)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed Stage 2 evaluation: %w", err)
+		return nil, stageError(StageStage2, fmt.Errorf("failed Stage 2 evaluation: %w", err))
 	}
 
 	log.Printf("Stage 2 evaluation completed")
 
-	// Step 5: Parse and return structured result
-	result, err := p.parseEvaluationResult(stage2Result)
+	// Step 6: Parse and return structured result
+	result, err := decodeWithRepair[EvaluationResult](ctx, p, req, StageStage2, stage2Result)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse evaluation result: %w", err)
+		return nil, stageError(StageParse, fmt.Errorf("failed to parse evaluation result: %w", err))
+	}
+
+	output := &EvaluationOutput{Result: result, Analysis: analysis, FailedPages: failedPages}
+	if redaction != nil {
+		// Put the masked details back where the model referred to them
+		if err := redaction.RestoreJSON(output.Result); err != nil {
+			return nil, stageError(StageParse, fmt.Errorf("failed to restore redacted details: %w", err))
+		}
+		if err := redaction.RestoreJSON(output.Analysis); err != nil {
+			return nil, stageError(StageParse, fmt.Errorf("failed to restore redacted details: %w", err))
+		}
+		output.Redactions = redaction.Summary()
 	}
 
 	log.Printf("AI pipeline completed successfully")
-	return result, nil
+	return output, nil
 }
 
-// parseEvaluationResult extracts JSON from Gemini response
-func (p *Pipeline) parseEvaluationResult(response string) (*EvaluationResult, error) {
-	// Find JSON content in the response
-	start := strings.Index(response, "{")
-	end := strings.LastIndex(response, "}")
-
-	if start == -1 || end == -1 {
-		return nil, fmt.Errorf("no JSON found in response")
+// decodeWithRepair strictly decodes an LLM response into T. If the response is invalid,
+// the model is asked once to correct it before giving up.
+func decodeWithRepair[T any](ctx context.Context, p *Pipeline, req EvaluationRequest, stage, response string) (*T, error) {
+	var result T
+	decodeErr := decodeStrict(response, &result)
+	if decodeErr == nil {
+		return &result, nil
 	}
 
-	jsonStr := response[start : end+1]
+	log.Printf("Invalid %s response, asking the model to repair it: %v", stage, decodeErr)
 
-	var result EvaluationResult
-	err := json.Unmarshal([]byte(jsonStr), &result)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	prompt := buildRepairPrompt(response, decodeErr, result)
+	return withRetry(ctx, p.retryPolicy, stage+"_repair", req.OnAttempt, func(ctx context.Context) (*T, error) {
+		var repaired T
+		if err := p.llm.GenerateJSON(ctx, prompt, &repaired); err != nil {
+			return nil, err
+		}
+		return &repaired, nil
+	})
+}
+
+// readDocument returns the text of a stored file, extracting it unless it is cached
+func (p *Pipeline) readDocument(ctx context.Context, key string) (*util.Document, error) {
+	if p.cache != nil {
+		doc, err := p.cache.CachedDocument(ctx, key)
+		if err != nil {
+			log.Printf("Warning: failed to read cached text of %s: %v", key, err)
+		}
+		if doc != nil {
+			return doc, nil
+		}
 	}
 
-	// Validate the result
-	if result.CVMatchRate < 0 || result.CVMatchRate > 1 {
-		result.CVMatchRate = 0.5 // Default fallback
+	data, err := storage.ReadAll(ctx, p.files, key)
+	if err != nil {
+		return nil, err
 	}
-	if result.ProjectScore < 0 || result.ProjectScore > 10 {
-		result.ProjectScore = 5.0 // Default fallback
+	doc, err := p.fileReader.ReadBytes(data, key)
+	if err != nil {
+		return nil, err
 	}
 
-	return &result, nil
+	if p.cache != nil {
+		if err := p.cache.CacheDocument(ctx, key, doc); err != nil {
+			log.Printf("Warning: failed to cache text of %s: %v", key, err)
+		}
+	}
+	return doc, nil
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
 }
@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// buildStage1Prompt builds the initial analysis prompt shared by all LLM providers
+func buildStage1Prompt(candidate Candidate, chromaContext []string) string {
+	return fmt.Sprintf(`
+You are an expert CV and project evaluator. Analyze the provided CV and project report.
+
+%s
+%s
+%s
+
+Project Report Content:
+%s
+
+Please provide an initial analysis focusing on:
+1. Key skills and experience from the CV
+2. Project complexity and technical depth
+3. Alignment between CV skills and the job requirements
+4. Initial impressions and areas that need deeper evaluation
+
+Provide a structured analysis in JSON format with the following structure:
+{
+  "cv_skills": ["skill1", "skill2", ...],
+  "cv_experience_level": "junior/mid/senior",
+  "project_complexity": "low/medium/high",
+  "project_technologies": ["tech1", "tech2", ...],
+  "skill_alignment": "poor/fair/good/excellent",
+  "areas_for_deeper_evaluation": ["area1", "area2", ...]
+}
+`, jobDescriptionSection(candidate.JobDescription), guidelinesSection(chromaContext), cvSection(candidate), candidate.Report)
+}
+
+// buildStage2Prompt builds the refined evaluation prompt shared by all LLM providers
+func buildStage2Prompt(candidate Candidate, analysis *Stage1Analysis, chromaContext []string) string {
+	contextStr := strings.Join(chromaContext, "\n\n")
+	analysisJSON, _ := json.MarshalIndent(analysis, "", "  ")
+
+	return fmt.Sprintf(`
+You are an expert CV and project evaluator. Based on the initial analysis and additional context, provide a comprehensive evaluation.
+
+%s
+
+Initial Analysis:
+%s
+
+Additional Context from Knowledge Base:
+%s
+
+%s
+
+Project Report Content:
+%s
+
+Based on all this information, provide a comprehensive evaluation in the following JSON format:
+{
+  "cv_match_rate": 0.0-1.0,
+  "cv_feedback": "detailed feedback on CV quality, strengths, and areas for improvement",
+  "project_score": 0.0-10.0,
+  "project_feedback": "detailed feedback on project quality, technical implementation, and documentation",
+  "overall_summary": "comprehensive summary of the candidate's suitability and recommendations"
+}
+
+Scoring Guidelines:
+- cv_match_rate: How well the CV matches the job requirements (0.0 = no match, 1.0 = perfect match)
+- project_score: Overall project quality (0-10 scale, where 10 is exceptional)
+
+Provide constructive, specific feedback that helps the candidate improve.
+`, jobDescriptionSection(candidate.JobDescription), analysisJSON, contextStr, cvSection(candidate), candidate.Report)
+}
+
+// jobDescriptionSection renders the job requirements block shared by both stage prompts
+func jobDescriptionSection(jobDescription string) string {
+	if strings.TrimSpace(jobDescription) == "" {
+		return "No job description was provided. Judge the requirements from the role implied by the project report."
+	}
+
+	return fmt.Sprintf("Job Description / Requirements:\n%s", jobDescription)
+}
+
+// cvSection renders the CV for both stage prompts, as JSON sections when its headings were recognised
+func cvSection(candidate Candidate) string {
+	var note string
+	if candidate.Redacted {
+		note = "Personal details in the CV and project report were replaced with placeholders such as [NAME_1] or [EMAIL_1]; keep them as they are when referring to them.\n\n"
+	}
+
+	if len(candidate.CVSections) == 0 {
+		return fmt.Sprintf("%sCV Content:\n%s", note, candidate.CV)
+	}
+
+	sections, _ := json.MarshalIndent(candidate.CVSections, "", "  ")
+	return fmt.Sprintf(`%sCV Content, split into sections ("name" is one of header, summary, experience, education,
+skills, projects, certifications or other; "heading" is the heading used in the CV):
+%s`, note, sections)
+}
+
+// guidelinesSection renders the retrieved guidelines for the Stage 1 prompt, or nothing if none were found
+func guidelinesSection(chromaContext []string) string {
+	if len(chromaContext) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\nRelevant Evaluation Guidelines from Knowledge Base:\n%s\n", strings.Join(chromaContext, "\n\n"))
+}
+
+// buildRepairPrompt asks the model to fix an output that failed to decode or validate.
+// v is a value of the expected type, used to show the required JSON structure.
+func buildRepairPrompt(invalidOutput string, decodeErr error, v any) string {
+	structure, _ := json.MarshalIndent(v, "", "  ")
+
+	return fmt.Sprintf(`
+Your previous response could not be used because it was not valid for the required JSON format.
+
+Problem:
+%s
+
+Previous response:
+%s
+
+Return ONLY a corrected JSON object with exactly these fields (values shown are placeholders):
+%s
+
+Do not add any other fields, comments or Markdown formatting.
+`, decodeErr, invalidOutput, structure)
+}
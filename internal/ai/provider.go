@@ -0,0 +1,74 @@
+package ai
+
+import (
+	"aicvevaluator/internal/util"
+	"context"
+	"fmt"
+)
+
+// Supported LLM provider names
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+)
+
+// Candidate holds the inputs that are evaluated
+type Candidate struct {
+	CV             string
+	CVSections     []util.Section // CV split at its headings; nil if none were recognised
+	Report         string
+	JobDescription string // requirements the CV is matched against; may be empty
+
+	// Redacted is set when personal details in the CV and report were replaced with placeholders
+	Redacted bool
+}
+
+// LLMProvider is implemented by every backend the pipeline can use for evaluation
+type LLMProvider interface {
+	// Stage1Analysis performs the initial analysis of a CV and project report, guided by context
+	// retrieved for this candidate, and returns the model's raw JSON output; the pipeline decodes
+	// and validates it into a Stage1Analysis
+	Stage1Analysis(ctx context.Context, candidate Candidate, chromaContext []string) (string, error)
+
+	// Stage2Evaluation produces the final evaluation using the Stage 1 analysis and retrieved context
+	Stage2Evaluation(ctx context.Context, candidate Candidate, analysis *Stage1Analysis, chromaContext []string) (string, error)
+
+	// GenerateJSON asks the model for a JSON document and decodes it into out
+	GenerateJSON(ctx context.Context, prompt string, out any) error
+
+	Close() error
+}
+
+// ProviderConfig selects and configures an LLM provider
+type ProviderConfig struct {
+	Provider string
+	Model    string
+	APIKey   string
+	BaseURL  string // only used by the OpenAI-compatible provider
+
+	// Fake provider settings, see NewFakeProviderFromConfig
+	FakeScript string
+	FakeFaults string
+}
+
+// NewLLMProvider creates the provider selected in cfg
+func NewLLMProvider(ctx context.Context, cfg ProviderConfig) (LLMProvider, error) {
+	var provider LLMProvider
+	var err error
+
+	switch cfg.Provider {
+	case ProviderGemini, "":
+		provider, err = NewGeminiClient(ctx, cfg.APIKey, cfg.Model)
+	case ProviderOpenAI:
+		provider, err = NewOpenAIClient(cfg.BaseURL, cfg.APIKey, cfg.Model)
+	case ProviderFake:
+		provider, err = NewFakeProviderFromConfig(cfg.FakeScript, cfg.FakeFaults)
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %s", cfg.Provider)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
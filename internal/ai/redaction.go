@@ -0,0 +1,490 @@
+package ai
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PIIType is a kind of personal information the Redactor can mask
+type PIIType string
+
+const (
+	PIIEmail   PIIType = "email"
+	PIIPhone   PIIType = "phone"
+	PIIURL     PIIType = "url" // links such as LinkedIn or GitHub profiles
+	PIIAddress PIIType = "address"
+	PIIName    PIIType = "name"
+)
+
+// PIITypes lists every type the Redactor supports
+var PIITypes = []PIIType{PIIEmail, PIIPhone, PIIURL, PIIAddress, PIIName}
+
+// RedactionPolicy selects what is masked before candidate documents are sent to the LLM
+type RedactionPolicy struct {
+	Types []PIIType
+
+	// Names are masked wherever they appear as whole words, in addition to the candidate's
+	// own name found in the CV. Only used when PIIName is enabled.
+	Names []string
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+(?:'[A-Za-z0-9._%+-]+)*@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	urlPattern   = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>()"]+|\b(?:linkedin\.com|github\.com|gitlab\.com)/[^\s<>()"]+`)
+
+	// Phone numbers are told from dates and other numbers by their digit count, see isPhoneNumber
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{1,4}\)[ .-]?)?\d{2,5}(?:[ .-]?\d{2,5}){1,4}`)
+
+	// Amounts such as "Rp 150.000.000" have phone-like digit counts; they are recognised by
+	// thousands separators or a currency before them, see isAmount
+	thousandsPattern = regexp.MustCompile(`^\d{1,3}(?:[.,]\d{3})+$`)
+	currencyPattern  = regexp.MustCompile(`(?i)(?:\brp\.?|\bidr|\busd|\beur|\bsgd|[$€£¥])[ \t]*$`)
+
+	// Street addresses in English and Indonesian forms, e.g. "12 Baker Street" or "Jl. Sudirman No. 5";
+	// an Indonesian address runs on over capitalised words and numbers such as "Kav. 52"
+	addressPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\b\d{1,5}[A-Za-z]?[ \t]+(?:[A-Z][a-z]+[ \t]+){1,4}(?:Street|St|Avenue|Ave|Road|Rd|Boulevard|Blvd|Lane|Ln|Drive|Dr|Court|Ct|Way|Place|Pl|Square|Sq)\b\.?`),
+		regexp.MustCompile(`\b(?:Jl\.?|Jalan)[ \t]+[A-Z][\w.]*(?:[ \t]+(?:[A-Z][\w.]*|\d+[A-Za-z/]*)){0,6}(?:[ \t]*,[ \t]*No\.?[ \t]*\d+[A-Za-z]?)?`),
+	}
+
+	// Labelled values such as "Address: ..." or "Name: ..."
+	addressLabelPattern = regexp.MustCompile(`(?im)^[\s*_#-]*(?:address|home address|alamat)[\s*_]*:[\s*_]*(.+)$`)
+	nameLabelPattern    = regexp.MustCompile(`(?im)^[\s*_#-]*(?:full name|name|nama)[\s*_]*:[\s*_]*(.+)$`)
+
+	placeholderPattern = regexp.MustCompile(`\[(?:EMAIL|PHONE|URL|ADDRESS|NAME)_\d+\]`)
+)
+
+// notNameWords are words that make a CV's first line a title rather than a person's name
+var notNameWords = map[string]bool{
+	"curriculum": true, "vitae": true, "resume": true, "résumé": true, "cv": true, "profile": true,
+	"engineer": true, "developer": true, "software": true, "senior": true, "junior": true,
+	"manager": true, "analyst": true, "designer": true, "consultant": true, "scientist": true,
+	"backend": true, "frontend": true, "fullstack": true, "data": true, "lead": true,
+}
+
+// Redactor masks personal information in candidate documents with placeholders such as
+// [EMAIL_1], so it is not sent to the LLM provider. It is safe for concurrent use.
+type Redactor struct {
+	types map[PIIType]bool
+	names []string
+}
+
+// NewRedactor creates a redactor for policy
+func NewRedactor(policy RedactionPolicy) (*Redactor, error) {
+	r := &Redactor{types: make(map[PIIType]bool)}
+	for _, t := range policy.Types {
+		if !isPIIType(t) {
+			return nil, fmt.Errorf("unknown PII type %q", t)
+		}
+		r.types[t] = true
+	}
+	for _, name := range policy.Names {
+		if name = strings.TrimSpace(name); name != "" {
+			r.names = append(r.names, name)
+		}
+	}
+	return r, nil
+}
+
+func isPIIType(t PIIType) bool {
+	for _, known := range PIITypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadNameList reads names to redact from a file with one name per line; blank lines and
+// lines starting with # are ignored
+func ReadNameList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			names = append(names, line)
+		}
+	}
+	return names, scanner.Err()
+}
+
+// Start begins the redaction of one evaluation. The candidate's name is looked for in the
+// CV so it can be masked in every document of the evaluation.
+func (r *Redactor) Start(cv string) *Redaction {
+	red := &Redaction{
+		redactor:     r,
+		placeholders: make(map[string]string),
+		values:       make(map[string]string),
+		counters:     make(map[PIIType]int),
+		summaries:    make(map[string]*RedactionSummary),
+	}
+	if r.types[PIIName] {
+		red.names = buildNamePattern(append(candidateNames(cv), r.names...))
+	}
+	return red
+}
+
+// RedactionSummary reports a masked value without revealing it
+type RedactionSummary struct {
+	Type        PIIType `json:"type"`
+	Placeholder string  `json:"placeholder"`
+	Occurrences int     `json:"occurrences"`
+}
+
+// Redaction maps the placeholders used in one evaluation back to the values they replace.
+// A value gets the same placeholder in every document of the evaluation.
+type Redaction struct {
+	redactor *Redactor
+	names    *namePattern // nil if names are not redacted or none are known
+
+	placeholders map[string]string // type and normalised value -> placeholder
+	values       map[string]string // placeholder -> first value seen
+	counters     map[PIIType]int
+	order        []string // placeholders in order of first use
+	summaries    map[string]*RedactionSummary
+}
+
+// piiMatch is a span of text to mask
+type piiMatch struct {
+	start, end int
+	typ        PIIType
+	key        string // normalised value, so variants share a placeholder
+}
+
+// Redact returns text with the enabled types of personal information replaced by placeholders
+func (red *Redaction) Redact(text string) string {
+	matches := red.find(text)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.start])
+		b.WriteString(red.placeholder(m, text[m.start:m.end]))
+		last = m.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// find returns the non-overlapping matches in text, in order; a longer match wins over a
+// shorter one starting at the same position
+func (red *Redaction) find(text string) []piiMatch {
+	types := red.redactor.types
+	var matches []piiMatch
+
+	add := func(typ PIIType, spans [][]int, key func(string) string) {
+		for _, s := range spans {
+			value := text[s[0]:s[1]]
+			matches = append(matches, piiMatch{start: s[0], end: s[1], typ: typ, key: key(value)})
+		}
+	}
+
+	if types[PIIEmail] {
+		add(PIIEmail, emailPattern.FindAllStringIndex(text, -1), strings.ToLower)
+	}
+	if types[PIIURL] {
+		for _, s := range urlPattern.FindAllStringIndex(text, -1) {
+			// Punctuation ending a sentence is not part of the link
+			end := s[0] + len(strings.TrimRight(text[s[0]:s[1]], ".,;:!?"))
+			matches = append(matches, piiMatch{start: s[0], end: end, typ: PIIURL, key: strings.ToLower(text[s[0]:end])})
+		}
+	}
+	if types[PIIPhone] {
+		for _, s := range phonePattern.FindAllStringIndex(text, -1) {
+			if digits := digitsOf(text[s[0]:s[1]]); isPhoneNumber(digits) && !isAmount(text, s[0], s[1]) {
+				matches = append(matches, piiMatch{start: s[0], end: s[1], typ: PIIPhone, key: digits})
+			}
+		}
+	}
+	if types[PIIAddress] {
+		for _, pattern := range addressPatterns {
+			add(PIIAddress, pattern.FindAllStringIndex(text, -1), normaliseSpace)
+		}
+		for _, s := range addressLabelPattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := s[2], trimRightSpaceIndex(text, s[2], s[3])
+			if end > start {
+				matches = append(matches, piiMatch{start: start, end: end, typ: PIIAddress, key: normaliseSpace(text[start:end])})
+			}
+		}
+	}
+	if red.names != nil {
+		for _, s := range red.names.re.FindAllStringIndex(text, -1) {
+			if isWordBoundary(text, s[0], s[1]) {
+				matches = append(matches, piiMatch{start: s[0], end: s[1], typ: PIIName, key: red.names.lookup(text[s[0]:s[1]])})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].end > matches[j].end
+	})
+
+	kept := matches[:0]
+	end := 0
+	for _, m := range matches {
+		if m.start < end {
+			continue
+		}
+		// Parts of one name written next to each other, e.g. "Jane Doe" for "Jane A. Doe",
+		// share a single placeholder
+		if n := len(kept); n > 0 && m.typ == PIIName && kept[n-1].typ == PIIName && kept[n-1].key == m.key &&
+			strings.TrimSpace(text[kept[n-1].end:m.start]) == "" && !strings.Contains(text[kept[n-1].end:m.start], "\n") {
+			kept[n-1].end = m.end
+		} else {
+			kept = append(kept, m)
+		}
+		end = m.end
+	}
+	return kept
+}
+
+// placeholder returns the placeholder for a match, allocating one on first use
+func (red *Redaction) placeholder(m piiMatch, value string) string {
+	key := string(m.typ) + ":" + m.key
+	placeholder, ok := red.placeholders[key]
+	if !ok {
+		red.counters[m.typ]++
+		placeholder = fmt.Sprintf("[%s_%d]", strings.ToUpper(string(m.typ)), red.counters[m.typ])
+		if m.typ == PIIName {
+			value = red.names.display[m.key]
+		}
+		red.placeholders[key] = placeholder
+		red.values[placeholder] = value
+		red.order = append(red.order, placeholder)
+		red.summaries[placeholder] = &RedactionSummary{Type: m.typ, Placeholder: placeholder}
+	}
+	red.summaries[placeholder].Occurrences++
+	return placeholder
+}
+
+// Restore replaces the placeholders in text with the values they masked
+func (red *Redaction) Restore(text string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if value, ok := red.values[placeholder]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// RestoreJSON restores the placeholders in every string of v, which must be a pointer to a
+// JSON-encodable value such as an LLM result
+func (red *Redaction) RestoreJSON(v any) error {
+	if len(red.values) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	restored := placeholderPattern.ReplaceAllFunc(data, func(placeholder []byte) []byte {
+		value, ok := red.values[string(placeholder)]
+		if !ok {
+			return placeholder
+		}
+		quoted, _ := json.Marshal(value)
+		return quoted[1 : len(quoted)-1]
+	})
+
+	// Decode into a zeroed value, or maps would keep their keys with placeholders
+	target := reflect.ValueOf(v).Elem()
+	target.Set(reflect.Zero(target.Type()))
+	return json.Unmarshal(restored, v)
+}
+
+// Summary lists what was redacted, in order of first occurrence
+func (red *Redaction) Summary() []RedactionSummary {
+	summaries := make([]RedactionSummary, 0, len(red.order))
+	for _, placeholder := range red.order {
+		summaries = append(summaries, *red.summaries[placeholder])
+	}
+	return summaries
+}
+
+// namePattern matches known names as whole words
+type namePattern struct {
+	re        *regexp.Regexp
+	canonical map[string]string // matched text -> the full name it belongs to, lower-cased
+	display   map[string]string // lower-cased full name -> the name as it is restored
+}
+
+// buildNamePattern matches each full name and its parts, as written, in title case and in
+// upper case. Parts are matched case-sensitively so that names that are also common words
+// are only masked when capitalised.
+func buildNamePattern(names []string) *namePattern {
+	canonical := make(map[string]string)
+	display := make(map[string]string)
+	for _, name := range names {
+		full := normaliseSpace(name)
+		if _, ok := display[strings.ToLower(full)]; !ok {
+			// Names are often capitalised in CV headers; restore them in title case
+			if full == strings.ToUpper(full) {
+				display[strings.ToLower(full)] = titleCase(full)
+			} else {
+				display[strings.ToLower(full)] = full
+			}
+		}
+		variants := []string{full}
+		for _, part := range strings.Fields(full) {
+			if len([]rune(part)) > 1 {
+				variants = append(variants, part)
+			}
+		}
+		for _, variant := range variants {
+			for _, form := range []string{variant, titleCase(variant), strings.ToUpper(variant)} {
+				if _, ok := canonical[form]; !ok {
+					canonical[form] = strings.ToLower(full)
+				}
+			}
+		}
+	}
+	if len(canonical) == 0 {
+		return nil
+	}
+
+	forms := make([]string, 0, len(canonical))
+	for form := range canonical {
+		forms = append(forms, form)
+	}
+	// Longest first, so a full name is masked as a whole rather than part by part
+	sort.Slice(forms, func(i, j int) bool {
+		if len(forms[i]) != len(forms[j]) {
+			return len(forms[i]) > len(forms[j])
+		}
+		return forms[i] < forms[j]
+	})
+
+	quoted := make([]string, len(forms))
+	for i, form := range forms {
+		quoted[i] = strings.ReplaceAll(regexp.QuoteMeta(form), " ", `\s+`)
+	}
+	// Word boundaries are checked by isWordBoundary, as \b does not know non-ASCII letters
+	re := regexp.MustCompile(strings.Join(quoted, "|"))
+	return &namePattern{re: re, canonical: canonical, display: display}
+}
+
+// lookup returns the full name a match belongs to; whitespace inside it may differ from the
+// name as it was given
+func (p *namePattern) lookup(matched string) string {
+	return p.canonical[normaliseSpace(matched)]
+}
+
+// candidateNames returns the candidate's name as found in a "Name:" line or, failing that,
+// the first line of the CV if it looks like a person's name
+func candidateNames(cv string) []string {
+	var names []string
+	for _, m := range nameLabelPattern.FindAllStringSubmatch(cv, -1) {
+		if name := strings.Trim(strings.TrimSpace(m[1]), "*_"); looksLikeName(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		return names
+	}
+
+	for _, line := range strings.Split(cv, "\n") {
+		line = strings.Trim(strings.TrimSpace(line), "#*_ ")
+		if line == "" {
+			continue
+		}
+		if looksLikeName(line) {
+			names = append(names, line)
+		}
+		break
+	}
+	return names
+}
+
+// looksLikeName reports whether s is two to four capitalised words made of letters
+func looksLikeName(s string) bool {
+	words := strings.Fields(s)
+	if len(words) < 2 || len(words) > 4 {
+		return false
+	}
+	for _, word := range words {
+		if notNameWords[strings.ToLower(word)] {
+			return false
+		}
+		for i, c := range word {
+			switch {
+			case i == 0 && !unicode.IsUpper(c):
+				return false
+			case !unicode.IsLetter(c) && c != '-' && c != '\'' && c != '’' && c != '.':
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// titleCase upper-cases the first letter of each word and lower-cases the rest
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// isWordBoundary reports whether text[start:end] is neither preceded nor followed by a letter or digit
+func isWordBoundary(text string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	isWord := func(c rune) bool { return unicode.IsLetter(c) || unicode.IsDigit(c) }
+	return (start == 0 || !isWord(before)) && (end == len(text) || !isWord(after))
+}
+
+func normaliseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func digitsOf(s string) string {
+	return strings.Map(func(c rune) rune {
+		if c >= '0' && c <= '9' {
+			return c
+		}
+		return -1
+	}, s)
+}
+
+// isPhoneNumber tells phone numbers from dates, years and amounts, which have fewer digits
+func isPhoneNumber(digits string) bool {
+	return len(digits) >= 9 && len(digits) <= 15
+}
+
+// isAmount reports whether the number text[start:end] is a sum of money rather than a phone number
+func isAmount(text string, start, end int) bool {
+	return thousandsPattern.MatchString(text[start:end]) || currencyPattern.MatchString(text[max(0, start-8):start])
+}
+
+// trimRightSpaceIndex returns end moved back over trailing whitespace and markup in text[start:end]
+func trimRightSpaceIndex(text string, start, end int) int {
+	for end > start && strings.ContainsRune(" \t\r*_", rune(text[end-1])) {
+		end--
+	}
+	return end
+}
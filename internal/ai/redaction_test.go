@@ -0,0 +1,292 @@
+package ai
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTestRedaction starts a redaction of cv with the given types enabled
+func newTestRedaction(t *testing.T, cv string, types ...PIIType) *Redaction {
+	t.Helper()
+	r, err := NewRedactor(RedactionPolicy{Types: types})
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+	return r.Start(cv)
+}
+
+func TestRedactTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  PIIType
+		text string
+		want string
+	}{
+		{"email", PIIEmail, "Contact: jane.doe+cv@example.co.id, or HR", "Contact: [EMAIL_1], or HR"},
+		{"international phone", PIIPhone, "Phone: +62 812-3456-7890", "Phone: [PHONE_1]"},
+		{"local phone", PIIPhone, "Call (021) 555 1234 after 5pm", "Call [PHONE_1] after 5pm"},
+		{"dotted phone", PIIPhone, "Mobile 0812.3456.7890", "Mobile [PHONE_1]"},
+		{"url", PIIURL, "See https://linkedin.com/in/janedoe.", "See [URL_1]."},
+		{"bare profile url", PIIURL, "Code at github.com/janedoe, mostly Go", "Code at [URL_1], mostly Go"},
+		{"www url", PIIURL, "Blog: www.janedoe.dev", "Blog: [URL_1]"},
+		{"street address", PIIAddress, "Lives at 221B Baker Street, London", "Lives at [ADDRESS_1], London"},
+		{"indonesian address", PIIAddress, "Jl. Sudirman Kav. 52 No. 5, Jakarta", "[ADDRESS_1], Jakarta"},
+		{"jalan address", PIIAddress, "Jalan Merdeka No 10", "[ADDRESS_1]"},
+		{"labelled address", PIIAddress, "Address: Apartment 4, Green Tower, Bandung\nSkills: Go", "Address: [ADDRESS_1]\nSkills: Go"},
+		{"labelled alamat", PIIAddress, "**Alamat:** Perumahan Indah Blok C/7", "**Alamat:** [ADDRESS_1]"},
+		{"name from header", PIIName, "Jane Doe\nBackend engineer", "[NAME_1]\nBackend engineer"},
+		{"name label", PIIName, "Name: Budi Santoso\nJakarta", "Name: [NAME_1]\nJakarta"},
+		{"non-ascii name", PIIName, "José García\nIngeniero", "[NAME_1]\nIngeniero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			red := newTestRedaction(t, tt.text, tt.typ)
+			if got := red.Redact(tt.text); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactFalsePositives(t *testing.T) {
+	texts := []string{
+		"Managed a budget of Rp 150.000.000 per quarter",
+		"Salary expectation: IDR 15.000.000",
+		"Cut costs by Rp150000000 a year",
+		"Raised $ 2500000000 in funding",
+		"Served 10.000.000 requests per day",
+		"Handled 1,250,000,000 events",
+		"Worked there 2019-2023, then 2023 - 2024",
+		"Started 2021-03-15, GPA 3.85/4.00",
+		"Upgraded from Kubernetes 1.28.4 to 1.30.1",
+		"Order ID 12345",
+		"Redis at localhost:6379 and port 8080",
+		"Jane Street is a trading firm", // not a name unless it is the candidate's
+		"Skills: Go, PostgreSQL, Docker",
+	}
+
+	red := newTestRedaction(t, "", PIITypes...)
+	for _, text := range texts {
+		if got := red.Redact(text); got != text {
+			t.Errorf("Redact(%q) = %q, want it unchanged", text, got)
+		}
+	}
+}
+
+func TestRedactPolicySelectsTypes(t *testing.T) {
+	text := "jane@example.com, +62 812 3456 7890, https://janedoe.dev"
+	red := newTestRedaction(t, text, PIIEmail)
+	if got, want := red.Redact(text), "[EMAIL_1], +62 812 3456 7890, https://janedoe.dev"; got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+
+	if _, err := NewRedactor(RedactionPolicy{Types: []PIIType{"ssn"}}); err == nil {
+		t.Error("NewRedactor() accepted an unknown PII type")
+	}
+}
+
+func TestRedactOverlaps(t *testing.T) {
+	tests := []struct {
+		name  string
+		cv    string
+		types []PIIType
+		text  string
+		want  string
+	}{
+		{
+			name:  "email wins over a name inside it",
+			cv:    "Jane Doe",
+			types: []PIIType{PIIEmail, PIIName},
+			text:  "Mail Jane.Doe@example.com",
+			want:  "Mail [EMAIL_1]",
+		},
+		{
+			name:  "url wins over a phone number inside it",
+			types: []PIIType{PIIURL, PIIPhone},
+			text:  "Chat on https://wa.me/6281234567890 today",
+			want:  "Chat on [URL_1] today",
+		},
+		{
+			name:  "url wins over an email inside it",
+			types: []PIIType{PIIURL, PIIEmail},
+			text:  "https://example.com/contact?to=jane@example.com",
+			want:  "[URL_1]",
+		},
+		{
+			name:  "name inside an address is masked with the address",
+			cv:    "Name: Budi Santoso",
+			types: []PIIType{PIIAddress, PIIName},
+			text:  "Jl. Budi Santoso No. 3",
+			want:  "[ADDRESS_1]",
+		},
+		{
+			name:  "adjacent matches are kept apart",
+			types: []PIIType{PIIEmail, PIIPhone},
+			text:  "jane@example.com +62 812 3456 7890",
+			want:  "[EMAIL_1] [PHONE_1]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			red := newTestRedaction(t, tt.cv, tt.types...)
+			if got := red.Redact(tt.text); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactNames(t *testing.T) {
+	cv := "JANE AMELIA DOE\nBackend Engineer"
+	red := newTestRedaction(t, cv, PIIName)
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		// Parts of the name written next to each other share one placeholder
+		{"JANE AMELIA DOE", "[NAME_1]"},
+		{"Jane Doe led the team", "[NAME_1] led the team"},
+		{"Jane  Amelia\tDoe", "[NAME_1]"},
+		{"Ms. Doe and Jane", "Ms. [NAME_1] and [NAME_1]"},
+		// A line break separates two mentions
+		{"Jane\nDoe", "[NAME_1]\n[NAME_1]"},
+		// Parts are whole words, and lower-case words are not names
+		{"Janet Doeville", "Janet Doeville"},
+		{"jane doe", "jane doe"},
+	}
+	for _, tt := range tests {
+		if got := red.Redact(tt.text); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	// Names given in the policy are masked too, each with its own placeholder
+	r, err := NewRedactor(RedactionPolicy{Types: []PIIType{PIIName}, Names: []string{"Acme Widgets", " "}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	red = r.Start(cv)
+	if got, want := red.Redact("Jane Doe at Acme Widgets"), "[NAME_1] at [NAME_2]"; got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+}
+
+func TestCandidateNames(t *testing.T) {
+	tests := []struct {
+		cv   string
+		want []string
+	}{
+		{"Jane Doe\nEngineer", []string{"Jane Doe"}},
+		{"# **Jane Doe**\n", []string{"Jane Doe"}},
+		{"\n\n  Budi Santoso  \n", []string{"Budi Santoso"}},
+		{"Curriculum Vitae\nName: Siti Rahma Putri", []string{"Siti Rahma Putri"}},
+		{"Nama: Siti Rahma\nFull Name: Siti Rahma Putri", []string{"Siti Rahma", "Siti Rahma Putri"}},
+		{"Senior Backend Engineer\nJane Doe", nil},
+		{"Jane\nDoe", nil},
+		{"Jane Doe, 2024", nil},
+		{"jane doe", nil},
+	}
+	for _, tt := range tests {
+		if got := candidateNames(tt.cv); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("candidateNames(%q) = %q, want %q", tt.cv, got, tt.want)
+		}
+	}
+}
+
+func TestRedactPlaceholdersAreStable(t *testing.T) {
+	red := newTestRedaction(t, "", PIIEmail, PIIPhone)
+
+	cv := red.Redact("jane@example.com, JANE@Example.com, +62 812 3456 7890")
+	report := red.Redact("Reach jane@example.com or ops@example.com at 0812-3456-7890")
+
+	if want := "[EMAIL_1], [EMAIL_1], [PHONE_1]"; cv != want {
+		t.Errorf("CV = %q, want %q", cv, want)
+	}
+	// Phone numbers share a placeholder by their digits only, so a local form is a new one
+	if want := "Reach [EMAIL_1] or [EMAIL_2] at [PHONE_2]"; report != want {
+		t.Errorf("report = %q, want %q", report, want)
+	}
+
+	want := []RedactionSummary{
+		{Type: PIIEmail, Placeholder: "[EMAIL_1]", Occurrences: 3},
+		{Type: PIIPhone, Placeholder: "[PHONE_1]", Occurrences: 1},
+		{Type: PIIEmail, Placeholder: "[EMAIL_2]", Occurrences: 1},
+		{Type: PIIPhone, Placeholder: "[PHONE_2]", Occurrences: 1},
+	}
+	if got := red.Summary(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Summary() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRestoreRoundTrip(t *testing.T) {
+	text := "Jane Doe\njane@example.com | +62 812 3456 7890 | https://github.com/janedoe\nAddress: 12 Baker Street, London\n\nBuilt APIs in Go."
+	red := newTestRedaction(t, text, PIITypes...)
+
+	redacted := red.Redact(text)
+	for _, value := range []string{"Jane", "jane@example.com", "3456", "github.com", "Baker"} {
+		if strings.Contains(redacted, value) {
+			t.Errorf("Redact() left %q in %q", value, redacted)
+		}
+	}
+	if got := red.Restore(redacted); got != text {
+		t.Errorf("Restore(Redact(text)) = %q, want %q", got, text)
+	}
+
+	// Placeholders the redaction did not hand out are left alone
+	if got := red.Restore("[EMAIL_9] and [NAME_1]"); got != "[EMAIL_9] and Jane Doe" {
+		t.Errorf("Restore() = %q", got)
+	}
+}
+
+func TestRestoreUpperCaseName(t *testing.T) {
+	red := newTestRedaction(t, "JANE DOE\nEngineer", PIIName)
+	red.Redact("JANE DOE")
+	if got, want := red.Restore("[NAME_1] is strong in Go"), "Jane Doe is strong in Go"; got != want {
+		t.Errorf("Restore() = %q, want %q", got, want)
+	}
+}
+
+func TestRestoreJSON(t *testing.T) {
+	text := "Address: 5 \"Green\" Lane, Flat \\2\nMail o'brien@example.com"
+	red := newTestRedaction(t, text, PIIAddress, PIIEmail)
+	if got, want := red.Redact(text), "Address: [ADDRESS_1]\nMail [EMAIL_1]"; got != want {
+		t.Fatalf("Redact() = %q, want %q", got, want)
+	}
+
+	type result struct {
+		Summary string            `json:"summary"`
+		Notes   []string          `json:"notes"`
+		Extra   map[string]string `json:"extra"`
+		Score   float64           `json:"score"`
+	}
+	v := &result{
+		Summary: "Lives at [ADDRESS_1]",
+		Notes:   []string{"Contact [EMAIL_1]", "[PHONE_3] is unknown"},
+		Extra:   map[string]string{"[EMAIL_1]": "keys are restored too"},
+		Score:   4.5,
+	}
+	if err := red.RestoreJSON(v); err != nil {
+		t.Fatalf("RestoreJSON() error = %v", err)
+	}
+
+	want := &result{
+		Summary: "Lives at 5 \"Green\" Lane, Flat \\2",
+		Notes:   []string{"Contact o'brien@example.com", "[PHONE_3] is unknown"},
+		Extra:   map[string]string{"o'brien@example.com": "keys are restored too"},
+		Score:   4.5,
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("RestoreJSON() = %+v, want %+v", v, want)
+	}
+
+	// Nothing was redacted: the value is left as it is
+	empty := newTestRedaction(t, "", PIIEmail)
+	untouched := &result{Summary: "[EMAIL_1]"}
+	if err := empty.RestoreJSON(untouched); err != nil || untouched.Summary != "[EMAIL_1]" {
+		t.Errorf("RestoreJSON() = %+v, %v", untouched, err)
+	}
+}
@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"aicvevaluator/internal/chromadb"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Knowledge base categories, stored in each document's "category" metadata
+const (
+	categoryCVEvaluation       = "cv_evaluation"
+	categoryEvaluationCriteria = "evaluation_criteria"
+)
+
+// Retrieval limits
+const (
+	maxQueryTextChars     = 1000 // how much candidate text goes into a retrieval query
+	maxQueryListedEntries = 15   // how many skills or technologies are named in a query
+)
+
+// RetrievalPolicy controls how much knowledge base context each stage receives
+type RetrievalPolicy struct {
+	TopK        int     // results per query
+	MaxDistance float64 // matches farther than this are dropped; 0 keeps every match
+}
+
+// defaultGuidelines is used for Stage 2 when the knowledge base returns nothing
+var defaultGuidelines = []string{
+	"CV Evaluation: Assess technical skills, experience level, education, and presentation quality. Rate CV match from 0.0-1.0.",
+	"Project Evaluation: Assess code quality, complexity, documentation, and problem-solving approach. Rate project from 0.0-10.0.",
+}
+
+// retrievalQuery is a single knowledge base lookup restricted to one category
+type retrievalQuery struct {
+	category string
+	text     string
+}
+
+// stage1Queries builds queries from the candidate's raw CV and report, before anything is known about them
+func stage1Queries(candidate Candidate) []retrievalQuery {
+	cvQuery := truncate(candidate.CV, maxQueryTextChars)
+	if candidate.JobDescription != "" {
+		cvQuery += "\n" + truncate(candidate.JobDescription, maxQueryTextChars)
+	}
+
+	return []retrievalQuery{
+		{category: categoryCVEvaluation, text: cvQuery},
+		{category: categoryEvaluationCriteria, text: truncate(candidate.Report, maxQueryTextChars)},
+	}
+}
+
+// stage2Queries builds queries from what Stage 1 found: skills and experience level for the CV,
+// technologies, complexity and open questions for the project
+func stage2Queries(candidate Candidate, analysis *Stage1Analysis) []retrievalQuery {
+	var cvQuery strings.Builder
+	fmt.Fprintf(&cvQuery, "%s level candidate", analysis.CVExperienceLevel)
+	if len(analysis.CVSkills) > 0 {
+		fmt.Fprintf(&cvQuery, " with skills in %s", joinLimited(analysis.CVSkills))
+	}
+	fmt.Fprintf(&cvQuery, ". Skill alignment: %s.", analysis.SkillAlignment)
+	if candidate.JobDescription != "" {
+		cvQuery.WriteString("\n" + truncate(candidate.JobDescription, maxQueryTextChars))
+	}
+
+	var projectQuery strings.Builder
+	fmt.Fprintf(&projectQuery, "%s complexity project", analysis.ProjectComplexity)
+	if len(analysis.ProjectTechnologies) > 0 {
+		fmt.Fprintf(&projectQuery, " built with %s", joinLimited(analysis.ProjectTechnologies))
+	}
+	projectQuery.WriteString(".")
+	if len(analysis.AreasForDeeperEvaluation) > 0 {
+		fmt.Fprintf(&projectQuery, " Evaluate: %s.", joinLimited(analysis.AreasForDeeperEvaluation))
+	}
+
+	return []retrievalQuery{
+		{category: categoryCVEvaluation, text: cvQuery.String()},
+		{category: categoryEvaluationCriteria, text: projectQuery.String()},
+	}
+}
+
+// retrieve runs each query against the vector store and returns the unique matching documents.
+// Retrieval is best effort: failures are logged and the stage continues with what was found.
+func (p *Pipeline) retrieve(ctx context.Context, collection, stage string, queries []retrievalQuery) []string {
+	if p.store == nil {
+		return nil
+	}
+
+	var results []string
+	seen := make(map[string]bool)
+	for _, q := range queries {
+		if strings.TrimSpace(q.text) == "" {
+			continue
+		}
+
+		opts := chromadb.QueryOptions{
+			QueryText:  q.text,
+			NResults:   p.retrievalPolicy.TopK,
+			Collection: collection,
+			Where:      map[string]interface{}{"category": q.category},
+		}
+		documents, err := p.store.Query(ctx, opts)
+		if errors.Is(err, chromadb.ErrCollectionNotFound) && collection != "" {
+			// A track whose collection has not been seeded yet still gets the default guidelines
+			log.Printf("Knowledge base collection '%s' not found, using the default collection", collection)
+			collection, opts.Collection = "", ""
+			documents, err = p.store.Query(ctx, opts)
+		}
+		if err != nil {
+			log.Printf("Knowledge base %s query for %s failed, continuing without it: %v", stage, q.category, err)
+			continue
+		}
+
+		for _, doc := range documents {
+			if p.retrievalPolicy.MaxDistance > 0 && doc.Distance > p.retrievalPolicy.MaxDistance {
+				log.Printf("Dropped %s match %s for %s: distance %.4f exceeds %.4f", stage, doc.ID, q.category, doc.Distance, p.retrievalPolicy.MaxDistance)
+				continue
+			}
+			if seen[doc.ID] {
+				continue
+			}
+			seen[doc.ID] = true
+			log.Printf("Retrieved %s match %s for %s at distance %.4f", stage, doc.ID, q.category, doc.Distance)
+			results = append(results, doc.Content)
+		}
+	}
+
+	log.Printf("Retrieved %d context documents for %s", len(results), stage)
+	return results
+}
+
+// joinLimited joins at most maxQueryListedEntries items with commas
+func joinLimited(items []string) string {
+	if len(items) > maxQueryListedEntries {
+		items = items[:maxQueryListedEntries]
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,85 @@
+package ai
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy controls how transient LLM failures are retried
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Attempt records a single LLM call made while processing an evaluation
+type Attempt struct {
+	Stage      string    `json:"stage"`
+	Attempt    int       `json:"attempt"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+	Retryable  bool      `json:"retryable,omitempty"`
+}
+
+// AttemptRecorder is notified after every LLM call attempt
+type AttemptRecorder func(Attempt)
+
+// backoff returns the delay before the given retry using exponential backoff with full jitter
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(delay) + 1))
+}
+
+// withRetry runs fn until it succeeds, fails with a permanent error, or the attempts run out
+func withRetry[T any](ctx context.Context, policy RetryPolicy, stage string, record AttemptRecorder, fn func(context.Context) (T, error)) (T, error) {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var result T
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		startedAt := time.Now()
+		result, err = fn(ctx)
+
+		retryable := IsRetryable(err)
+		if record != nil {
+			a := Attempt{
+				Stage:      stage,
+				Attempt:    attempt,
+				StartedAt:  startedAt,
+				DurationMs: time.Since(startedAt).Milliseconds(),
+				Retryable:  retryable,
+			}
+			if err != nil {
+				a.Error = err.Error()
+			}
+			record(a)
+		}
+
+		if err == nil || !retryable || attempt == maxAttempts {
+			break
+		}
+
+		delay := policy.backoff(attempt)
+		log.Printf("%s attempt %d failed with retryable error, retrying in %s: %v", stage, attempt, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	return result, err
+}
@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// validator is implemented by LLM output types that check their own invariants after decoding
+type validator interface {
+	Validate() error
+}
+
+// schemaFor builds a Gemini response schema from the JSON shape of v.
+// Fields without omitempty are required; an `enum:"a,b,c"` tag restricts string values.
+func schemaFor(v any) *genai.Schema {
+	return schemaForType(reflect.TypeOf(v))
+}
+
+func schemaForType(t reflect.Type) *genai.Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &genai.Schema{Type: genai.TypeString}
+	case reflect.Bool:
+		return &genai.Schema{Type: genai.TypeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &genai.Schema{Type: genai.TypeInteger}
+	case reflect.Float32, reflect.Float64:
+		return &genai.Schema{Type: genai.TypeNumber}
+	case reflect.Slice, reflect.Array:
+		return &genai.Schema{Type: genai.TypeArray, Items: schemaForType(t.Elem())}
+	case reflect.Map:
+		return &genai.Schema{Type: genai.TypeObject}
+	case reflect.Struct:
+		schema := &genai.Schema{
+			Type:       genai.TypeObject,
+			Properties: make(map[string]*genai.Schema),
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			prop := schemaForType(field.Type)
+			if enum := field.Tag.Get("enum"); enum != "" {
+				prop.Format = "enum"
+				prop.Enum = strings.Split(enum, ",")
+			}
+			if desc := field.Tag.Get("description"); desc != "" {
+				prop.Description = desc
+			}
+
+			schema.Properties[name] = prop
+			if !strings.Contains(opts, "omitempty") {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		return schema
+	}
+
+	return &genai.Schema{Type: genai.TypeString}
+}
+
+// decodeStrict decodes a single JSON document into out, rejecting unknown fields,
+// trailing data and values that fail out's own validation
+func decodeStrict(text string, out any) error {
+	text = stripCodeFence(text)
+
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+	if dec.More() {
+		return fmt.Errorf("%w: unexpected data after JSON document", ErrInvalidResponse)
+	}
+
+	if err := checkRequired(text, out); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+	}
+
+	if v, ok := out.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+		}
+	}
+	return nil
+}
+
+// checkRequired verifies that every required top-level property of out's schema is present in text
+func checkRequired(text string, out any) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(text), &fields); err != nil {
+		return nil // not an object; the typed decode has already validated the shape
+	}
+
+	var missing []string
+	for _, name := range schemaFor(out).Required {
+		if raw, ok := fields[name]; !ok || bytes.Equal(raw, []byte("null")) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("missing required fields: " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// stripCodeFence removes a surrounding Markdown code fence, which some models add despite instructions
+func stripCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+
+	text = strings.TrimPrefix(text, "```")
+	if newline := strings.IndexByte(text, '\n'); newline >= 0 {
+		text = text[newline+1:] // drop the language tag, e.g. ```json
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+}
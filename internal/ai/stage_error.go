@@ -0,0 +1,70 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Pipeline stages, used to report where an evaluation failed. Retrieval is best effort and
+// falls back to the default guidelines, so it never fails an evaluation.
+const (
+	StageRead   = "read"
+	StageStage1 = "stage1"
+	StageStage2 = "stage2"
+	StageParse  = "parse"
+)
+
+// Error codes stored on failed evaluations
+const (
+	CodeQuotaExceeded   = "quota_exceeded"
+	CodeModelNotFound   = "model_not_found"
+	CodeSafetyBlocked   = "safety_blocked"
+	CodeLLMUnavailable  = "llm_unavailable"
+	CodeEmptyResponse   = "empty_response"
+	CodeInvalidResponse = "invalid_response"
+	CodeFileUnreadable  = "file_unreadable"
+	CodeInternal        = "internal_error"
+)
+
+// ErrInvalidResponse is returned when the LLM output cannot be turned into a result
+var ErrInvalidResponse = errors.New("invalid LLM response")
+
+// StageError records the pipeline stage in which an error occurred
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns the error category for the failure
+func (e *StageError) Code() string {
+	switch {
+	case errors.Is(e.Err, ErrQuotaExceeded):
+		return CodeQuotaExceeded
+	case errors.Is(e.Err, ErrModelNotFound):
+		return CodeModelNotFound
+	case errors.Is(e.Err, ErrSafetyBlocked):
+		return CodeSafetyBlocked
+	case errors.Is(e.Err, ErrUnavailable):
+		return CodeLLMUnavailable
+	case errors.Is(e.Err, ErrEmptyResponse):
+		return CodeEmptyResponse
+	case errors.Is(e.Err, ErrInvalidResponse):
+		return CodeInvalidResponse
+	case e.Stage == StageRead:
+		return CodeFileUnreadable
+	}
+	return CodeInternal
+}
+
+// stageError wraps err with the stage it occurred in
+func stageError(stage string, err error) error {
+	return &StageError{Stage: stage, Err: err}
+}
@@ -0,0 +1,42 @@
+package ai
+
+import (
+	"aicvevaluator/internal/chromadb"
+	"context"
+	"errors"
+	"log"
+)
+
+// VectorStore is the knowledge base the pipeline retrieves evaluation guidelines from.
+// It is implemented by the ChromaDB HTTP client and the embedded in-process store.
+type VectorStore interface {
+	// Query returns the documents closest to opts.QueryText, closest first. It returns
+	// chromadb.ErrCollectionNotFound if opts.Collection does not exist.
+	Query(ctx context.Context, opts chromadb.QueryOptions) ([]chromadb.Document, error)
+}
+
+// fallbackStore answers from secondary whenever primary fails
+type fallbackStore struct {
+	primary   VectorStore
+	secondary VectorStore
+}
+
+// NewFallbackStore returns a VectorStore that queries primary and, if that fails, secondary,
+// e.g. ChromaDB backed by an embedded store so retrieval survives a ChromaDB outage
+func NewFallbackStore(primary, secondary VectorStore) VectorStore {
+	return &fallbackStore{primary: primary, secondary: secondary}
+}
+
+func (s *fallbackStore) Query(ctx context.Context, opts chromadb.QueryOptions) ([]chromadb.Document, error) {
+	documents, err := s.primary.Query(ctx, opts)
+	if err == nil || ctx.Err() != nil {
+		return documents, err
+	}
+
+	log.Printf("Primary vector store query failed, using the fallback store: %v", err)
+	documents, fallbackErr := s.secondary.Query(ctx, opts)
+	if fallbackErr != nil {
+		return nil, errors.Join(err, fallbackErr)
+	}
+	return documents, nil
+}
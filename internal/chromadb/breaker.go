@@ -0,0 +1,131 @@
+package chromadb
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting ChromaDB while the circuit breaker is open
+var ErrCircuitOpen = errors.New("ChromaDB circuit breaker is open")
+
+// CircuitState is the state of the client's circuit breaker
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // requests flow normally
+	CircuitOpen     CircuitState = "open"      // requests fail fast until the cooldown ends
+	CircuitHalfOpen CircuitState = "half_open" // one probe request decides whether to close again
+)
+
+// CircuitStatus is a snapshot of the circuit breaker, for health reporting
+type CircuitStatus struct {
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	LastError           string       `json:"last_error,omitempty"`
+}
+
+// circuitBreaker opens after threshold consecutive failed requests and lets a single
+// probe through once cooldown has passed. A threshold of 0 disables it.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	lastError string
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: CircuitClosed}
+}
+
+// rejecting reports whether requests currently fail fast, without claiming the probe slot
+func (b *circuitBreaker) rejecting() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == CircuitOpen && time.Since(b.openedAt) < b.cooldown
+}
+
+// allow returns ErrCircuitOpen if a request may not be sent now
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 {
+		return nil
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return nil
+	case CircuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// success records a request that reached a healthy server
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// failure records a request that failed because ChromaDB was unreachable or unhealthy
+func (b *circuitBreaker) failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.lastError = err.Error()
+	b.probing = false
+
+	if b.threshold > 0 && (b.state == CircuitHalfOpen || b.failures >= b.threshold) {
+		b.state = CircuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// abandon releases the probe slot of a request cancelled by its caller, which says nothing about ChromaDB
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if b.state == CircuitHalfOpen {
+		// Let the next request probe straight away
+		b.state = CircuitOpen
+		b.openedAt = time.Now().Add(-b.cooldown)
+	}
+}
+
+func (b *circuitBreaker) status() CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := CircuitStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if b.state != CircuitClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
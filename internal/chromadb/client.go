@@ -1,28 +1,76 @@
 package chromadb
 
 import (
-	"bytes"
 	"context"
-	"crypto/md5"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
-	"strings"
+	"sync"
 	"time"
 )
 
+// Defaults used when no tenant, database or collection option is given
 const (
-	collectionName = "evaluation_guidelines"
-	tenantID       = "default_tenant"
-	databaseID     = "default_database"
+	DefaultTenant     = "default_tenant"
+	DefaultDatabase   = "default_database"
+	DefaultCollection = "evaluation_guidelines"
 )
 
+// embedderMetadataKey is the collection metadata key recording which embedder produced its vectors
+const embedderMetadataKey = "embedder"
+
+// ErrCollectionNotFound is returned when a named collection does not exist
+var ErrCollectionNotFound = errors.New("collection not found")
+
 // Client represents a ChromaDB client
 type Client struct {
-	baseURL      string
-	httpClient   *http.Client
-	collectionID string // Store the UUID of the collection
+	baseURL    string
+	httpClient *http.Client
+	embedder   Embedder
+	tenant     string
+	database   string
+	collection string
+
+	initMu       sync.Mutex // guards collectionID
+	collectionID string     // Store the UUID of the collection
+
+	otherCollectionIDs sync.Map // UUIDs of other collections queried through QueryOptions.Collection
+
+	authHeader string
+	authToken  string
+	retry      RetryConfig
+	breaker    *circuitBreaker
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithTenant sets the tenant; it is created by InitializeCollection if missing
+func WithTenant(tenant string) Option {
+	return func(c *Client) {
+		if tenant != "" {
+			c.tenant = tenant
+		}
+	}
+}
+
+// WithDatabase sets the database; it is created by InitializeCollection if missing
+func WithDatabase(database string) Option {
+	return func(c *Client) {
+		if database != "" {
+			c.database = database
+		}
+	}
+}
+
+// WithCollection sets the collection name
+func WithCollection(collection string) Option {
+	return func(c *Client) {
+		if collection != "" {
+			c.collection = collection
+		}
+	}
 }
 
 // Document represents a document in ChromaDB
@@ -30,6 +78,7 @@ type Document struct {
 	ID       string                 `json:"id"`
 	Content  string                 `json:"document"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Distance float64                `json:"distance,omitempty"` // set by queries; lower is closer
 }
 
 // Collection represents a ChromaDB collection
@@ -39,31 +88,58 @@ type Collection struct {
 	Metadata map[string]interface{} `json:"metadata"`
 }
 
-// NewClient creates a new ChromaDB client
-func NewClient(baseURL string) (*Client, error) {
+// NewClient creates a new ChromaDB client that embeds documents and queries with embedder
+func NewClient(baseURL string, embedder Embedder, opts ...Option) (*Client, error) {
 	if baseURL == "" {
 		return nil, fmt.Errorf("ChromaDB URL is required")
 	}
+	if embedder == nil {
+		return nil, fmt.Errorf("an embedder is required")
+	}
 
 	client := &Client{
-		baseURL: baseURL,
+		baseURL:    baseURL,
+		embedder:   embedder,
+		tenant:     DefaultTenant,
+		database:   DefaultDatabase,
+		collection: DefaultCollection,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		retry:   DefaultRetryConfig,
+		breaker: newCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown),
+	}
+	for _, opt := range opts {
+		opt(client)
 	}
 
 	return client, nil
 }
 
-// InitializeCollection initializes the evaluation guidelines collection using v2 API
+// InitializeCollection initializes the evaluation guidelines collection using v2 API,
+// creating the tenant, database and collection if they do not exist
 func (c *Client) InitializeCollection(ctx context.Context) error {
-	endpoint := fmt.Sprintf("/api/v2/tenants/%s/databases/%s/collections", tenantID, databaseID)
+	c.initMu.Lock()
+	defer c.initMu.Unlock()
+	return c.initializeCollection(ctx)
+}
+
+// initializeCollection implements InitializeCollection; the caller holds initMu
+func (c *Client) initializeCollection(ctx context.Context) error {
+	if err := c.ensureDatabase(ctx); err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("/api/v2/tenants/%s/databases/%s/collections", c.tenant, c.database)
 
 	// Check if collection exists and get its UUID
-	collection, err := c.getCollection(ctx, endpoint)
+	collection, err := c.getCollection(ctx, endpoint, c.collection)
 	if err == nil {
+		if err := c.checkEmbedder(collection); err != nil {
+			return err
+		}
 		c.collectionID = collection.ID
-		fmt.Printf("✅ Collection '%s' already exists with ID: %s\n", collectionName, c.collectionID)
+		fmt.Printf("✅ Collection '%s' already exists with ID: %s\n", c.collection, c.collectionID)
 		return nil
 	}
 
@@ -74,198 +150,221 @@ func (c *Client) InitializeCollection(ctx context.Context) error {
 	}
 
 	c.collectionID = collection.ID
-	fmt.Printf("✅ Collection '%s' created successfully with ID: %s\n", collectionName, c.collectionID)
+	fmt.Printf("✅ Collection '%s' created successfully with ID: %s\n", c.collection, c.collectionID)
 	return nil
 }
 
 // getCollection gets collection by name and returns its details including UUID
-func (c *Client) getCollection(ctx context.Context, endpoint string) (*Collection, error) {
-	url := fmt.Sprintf("%s%s", c.baseURL, endpoint)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("failed to list collections: HTTP %d", resp.StatusCode)
-	}
-
+func (c *Client) getCollection(ctx context.Context, endpoint, name string) (*Collection, error) {
 	var collections []Collection
-	if err := json.NewDecoder(resp.Body).Decode(&collections); err != nil {
-		return nil, fmt.Errorf("failed to parse collections response: %w", err)
+	if err := c.doJSON(ctx, "GET", endpoint, nil, &collections); err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
 	}
 
 	// Find our collection by name
 	for _, collection := range collections {
-		if collection.Name == collectionName {
+		if collection.Name == name {
 			return &collection, nil
 		}
 	}
 
-	return nil, fmt.Errorf("collection not found")
+	return nil, ErrCollectionNotFound
 }
 
-// createCollection creates collection and returns its details including UUID
-func (c *Client) createCollection(ctx context.Context, endpoint string) (*Collection, error) {
-	url := fmt.Sprintf("%s%s", c.baseURL, endpoint)
-
-	reqBody := map[string]interface{}{
-		"name": collectionName,
-		"metadata": map[string]interface{}{
-			"description": "Evaluation guidelines for CV and project evaluation",
-		},
-		"get_or_create": true,
+// collectionIDFor returns the UUID of the named collection, looking up collections other than
+// the client's own on first use. It returns ErrCollectionNotFound if the collection is missing.
+// The client's own collection is initialized here if InitializeCollection has not succeeded
+// yet, so a client created while ChromaDB was down is used once ChromaDB recovers.
+func (c *Client) collectionIDFor(ctx context.Context, name string) (string, error) {
+	if name == "" || name == c.collection {
+		c.initMu.Lock()
+		defer c.initMu.Unlock()
+		if c.collectionID == "" {
+			if err := c.initializeCollection(ctx); err != nil {
+				return "", err
+			}
+		}
+		return c.collectionID, nil
 	}
 
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	if id, ok := c.otherCollectionIDs.Load(name); ok {
+		return id.(string), nil
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	endpoint := fmt.Sprintf("/api/v2/tenants/%s/databases/%s/collections", c.tenant, c.database)
+	collection, err := c.getCollection(ctx, endpoint, name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return "", err
+	}
+	if err := c.checkEmbedder(collection); err != nil {
+		return "", err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	c.otherCollectionIDs.Store(name, collection.ID)
+	return collection.ID, nil
+}
 
-	resp, err := c.httpClient.Do(req)
+// ensureDatabase creates the configured tenant and database if they do not exist
+func (c *Client) ensureDatabase(ctx context.Context) error {
+	err := c.doJSON(ctx, "GET", fmt.Sprintf("/api/v2/tenants/%s", c.tenant), nil, nil)
+	if isNotFound(err) {
+		log.Printf("Creating ChromaDB tenant '%s'", c.tenant)
+		err = c.doJSON(ctx, "POST", "/api/v2/tenants", map[string]string{"name": c.tenant}, nil)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return fmt.Errorf("failed to ensure tenant '%s': %w", c.tenant, err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("HTTP %d - %s", resp.StatusCode, string(respBody))
+	err = c.doJSON(ctx, "GET", fmt.Sprintf("/api/v2/tenants/%s/databases/%s", c.tenant, c.database), nil, nil)
+	if isNotFound(err) {
+		log.Printf("Creating ChromaDB database '%s' in tenant '%s'", c.database, c.tenant)
+		err = c.doJSON(ctx, "POST", fmt.Sprintf("/api/v2/tenants/%s/databases", c.tenant), map[string]string{"name": c.database}, nil)
 	}
-
-	var collection Collection
-	if err := json.NewDecoder(resp.Body).Decode(&collection); err != nil {
-		return nil, fmt.Errorf("failed to parse collection response: %w", err)
+	if err != nil {
+		return fmt.Errorf("failed to ensure database '%s': %w", c.database, err)
 	}
 
-	return &collection, nil
+	return nil
 }
 
-// generateSimpleEmbedding generates a simple embedding vector for text
-func (c *Client) generateSimpleEmbedding(text string) []float64 {
-	// Create a simple embedding based on text characteristics
-	embedding := make([]float64, 384) // Common embedding size
-
-	// Hash-based approach for consistent embeddings
-	hash := md5.Sum([]byte(strings.ToLower(text)))
+// ListCollections returns every collection in the database
+func (c *Client) ListCollections(ctx context.Context) ([]Collection, error) {
+	endpoint := fmt.Sprintf("/api/v2/tenants/%s/databases/%s/collections", c.tenant, c.database)
 
-	// Convert hash bytes to float values
-	for i := 0; i < len(embedding); i++ {
-		byteIndex := i % len(hash)
-		// Normalize to [-1, 1] range
-		embedding[i] = (float64(hash[byteIndex]) - 127.5) / 127.5
+	var collections []Collection
+	if err := c.doJSON(ctx, "GET", endpoint, nil, &collections); err != nil {
+		return nil, err
 	}
+	return collections, nil
+}
 
-	// Add some text-based features
-	textLen := float64(len(text))
-	wordCount := float64(len(strings.Fields(text)))
-
-	if len(embedding) > 2 {
-		embedding[0] = textLen / 1000.0  // Normalize text length
-		embedding[1] = wordCount / 100.0 // Normalize word count
+// DeleteCollection deletes the client's collection and every document in it.
+// It does not need InitializeCollection, so it also works when the stored embedder does not match.
+func (c *Client) DeleteCollection(ctx context.Context) error {
+	endpoint := fmt.Sprintf("/api/v2/tenants/%s/databases/%s/collections/%s", c.tenant, c.database, c.collection)
+	if err := c.doJSON(ctx, "DELETE", endpoint, nil, nil); err != nil {
+		return err
 	}
 
-	return embedding
+	c.initMu.Lock()
+	c.collectionID = ""
+	c.initMu.Unlock()
+	return nil
 }
 
-// AddDocument adds a document to the ChromaDB collection using collection UUID
-func (c *Client) AddDocument(ctx context.Context, id, content string, metadata map[string]interface{}) error {
+// initializedCollectionID returns the UUID found by InitializeCollection, or an error if it has
+// not been called successfully
+func (c *Client) initializedCollectionID() (string, error) {
+	c.initMu.Lock()
+	defer c.initMu.Unlock()
 	if c.collectionID == "" {
-		return fmt.Errorf("collection not initialized - no collection ID")
+		return "", fmt.Errorf("collection not initialized - no collection ID")
 	}
+	return c.collectionID, nil
+}
 
-	endpoint := fmt.Sprintf("/api/v2/tenants/%s/databases/%s/collections/%s/add", tenantID, databaseID, c.collectionID)
-	url := fmt.Sprintf("%s%s", c.baseURL, endpoint)
-
-	// Generate embedding for the content
-	embedding := c.generateSimpleEmbedding(content)
+// CollectionName returns the name of the collection the client works on
+func (c *Client) CollectionName() string {
+	return c.collection
+}
 
+// createCollection creates collection and returns its details including UUID
+func (c *Client) createCollection(ctx context.Context, endpoint string) (*Collection, error) {
 	reqBody := map[string]interface{}{
-		"ids":        []string{id},
-		"documents":  []string{content},
-		"metadatas":  []map[string]interface{}{metadata},
-		"embeddings": [][]float64{embedding},
+		"name": c.collection,
+		"metadata": map[string]interface{}{
+			"description":       "Evaluation guidelines for CV and project evaluation",
+			embedderMetadataKey: c.embedder.Identity(),
+		},
+		"get_or_create": true,
 	}
 
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+	var collection Collection
+	if err := c.doJSON(ctx, "POST", endpoint, reqBody, &collection); err != nil {
+		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	return &collection, nil
+}
+
+// checkEmbedder refuses to use a collection whose vectors came from a different embedder,
+// since similarity scores between vectors from different models are meaningless
+func (c *Client) checkEmbedder(collection *Collection) error {
+	identity, _ := collection.Metadata[embedderMetadataKey].(string)
+	if identity == "" {
+		log.Printf("Warning: collection '%s' does not record its embedder; re-seed it if results look random", collection.Name)
+		return nil
 	}
+	if identity != c.embedder.Identity() {
+		return fmt.Errorf("collection '%s' was built with embedder %q but %q is configured; delete and re-seed the collection",
+			collection.Name, identity, c.embedder.Identity())
+	}
+	return nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
+// EmbedderIdentity returns the identity of the configured embedder
+func (c *Client) EmbedderIdentity() string {
+	return c.embedder.Identity()
+}
+
+// AddDocument adds a document to the ChromaDB collection using collection UUID
+func (c *Client) AddDocument(ctx context.Context, id, content string, metadata map[string]interface{}) error {
+	if _, err := c.initializedCollectionID(); err != nil {
+		return err
+	}
 
-	resp, err := c.httpClient.Do(req)
+	// Generate embedding for the content
+	embeddings, err := c.embedder.EmbedDocuments(ctx, []string{content})
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return fmt.Errorf("failed to embed document: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTP %d - %s", resp.StatusCode, string(respBody))
+	reqBody := map[string]interface{}{
+		"ids":        []string{id},
+		"documents":  []string{content},
+		"metadatas":  []map[string]interface{}{metadata},
+		"embeddings": embeddings,
 	}
 
-	return nil
+	return c.doCollection(ctx, "POST", "add", reqBody, nil)
 }
 
 // QueryDocuments queries documents from ChromaDB using collection UUID
 func (c *Client) QueryDocuments(ctx context.Context, queryText string, n int) ([]Document, error) {
-	if c.collectionID == "" {
-		return nil, fmt.Errorf("collection not initialized - no collection ID")
-	}
-
-	endpoint := fmt.Sprintf("/api/v2/tenants/%s/databases/%s/collections/%s/query", tenantID, databaseID, c.collectionID)
-	url := fmt.Sprintf("%s%s", c.baseURL, endpoint)
-
-	// Generate embedding for the query
-	queryEmbedding := c.generateSimpleEmbedding(queryText)
+	return c.Query(ctx, QueryOptions{QueryText: queryText, NResults: n})
+}
 
-	reqBody := map[string]interface{}{
-		"query_embeddings": [][]float64{queryEmbedding},
-		"n_results":        n,
-		"include":          []string{"documents", "metadatas", "distances"},
+// Query runs a similarity search described by opts and returns the matches, closest first
+func (c *Client) Query(ctx context.Context, opts QueryOptions) ([]Document, error) {
+	if opts.NResults <= 0 {
+		return nil, fmt.Errorf("NResults must be positive")
 	}
-
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	if c.breaker.rejecting() {
+		// Skip embedding the query too; it may be a paid API call
+		return nil, ErrCircuitOpen
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	collectionID, err := c.collectionIDFor(ctx, opts.Collection)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
+	// Generate embedding for the query
+	queryEmbedding, err := c.embedder.EmbedQuery(ctx, opts.QueryText)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to embed query: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("HTTP %d - %s", resp.StatusCode, string(respBody))
+	reqBody := map[string]interface{}{
+		"query_embeddings": [][]float64{queryEmbedding},
+		"n_results":        opts.NResults,
+		"include":          opts.include(),
+	}
+	if len(opts.Where) > 0 {
+		reqBody["where"] = opts.Where
+	}
+	if len(opts.WhereDocument) > 0 {
+		reqBody["where_document"] = opts.WhereDocument
 	}
 
 	var result struct {
@@ -275,21 +374,28 @@ func (c *Client) QueryDocuments(ctx context.Context, queryText string, n int) ([
 		Distances [][]float64                `json:"distances"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err := c.doCollectionID(ctx, "POST", collectionID, "query", reqBody, &result); err != nil {
+		return nil, err
 	}
 
-	// Convert to Document slice
+	// Convert to Document slice; only the first query's results are present
 	documents := make([]Document, 0)
-	if len(result.Documents) > 0 && len(result.Documents[0]) > 0 {
-		for i := range result.Documents[0] {
-			doc := Document{
-				ID:      result.IDs[0][i],
-				Content: result.Documents[0][i],
+	if len(result.IDs) > 0 {
+		for i, id := range result.IDs[0] {
+			doc := Document{ID: id}
+			if len(result.Documents) > 0 && len(result.Documents[0]) > i {
+				doc.Content = result.Documents[0][i]
 			}
 			if len(result.Metadatas) > 0 && len(result.Metadatas[0]) > i {
 				doc.Metadata = result.Metadatas[0][i]
 			}
+			if len(result.Distances) > 0 && len(result.Distances[0]) > i {
+				doc.Distance = result.Distances[0][i]
+			}
+
+			if opts.MaxDistance > 0 && doc.Distance > opts.MaxDistance {
+				continue
+			}
 			documents = append(documents, doc)
 		}
 	}
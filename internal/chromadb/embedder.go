@@ -0,0 +1,59 @@
+package chromadb
+
+import (
+	"context"
+	"fmt"
+)
+
+// Supported embedding providers
+const (
+	EmbedderGemini = "gemini"
+	EmbedderOpenAI = "openai"
+	EmbedderLocal  = "local"
+)
+
+// Embedder turns text into vectors for storage and similarity search
+type Embedder interface {
+	// EmbedDocuments returns one vector per text, for content stored in the collection
+	EmbedDocuments(ctx context.Context, texts []string) ([][]float64, error)
+
+	// EmbedQuery returns the vector used to search the collection
+	EmbedQuery(ctx context.Context, text string) ([]float64, error)
+
+	// Identity names the provider and model, e.g. "gemini:text-embedding-004".
+	// Vectors from different identities are not comparable.
+	Identity() string
+
+	// Close releases any resources held by the embedder
+	Close() error
+}
+
+// EmbedderConfig selects and configures an embedder
+type EmbedderConfig struct {
+	Provider string
+	Model    string
+	APIKey   string
+	BaseURL  string // only used by the OpenAI-compatible embedder
+}
+
+// NewEmbedder creates the embedder selected in cfg
+func NewEmbedder(ctx context.Context, cfg EmbedderConfig) (Embedder, error) {
+	var embedder Embedder
+	var err error
+
+	switch cfg.Provider {
+	case EmbedderLocal, "":
+		embedder = NewHashingEmbedder(0)
+	case EmbedderGemini:
+		embedder, err = NewGeminiEmbedder(ctx, cfg.APIKey, cfg.Model)
+	case EmbedderOpenAI:
+		embedder, err = NewOpenAIEmbedder(cfg.BaseURL, cfg.APIKey, cfg.Model)
+	default:
+		return nil, fmt.Errorf("unknown embedding provider: %s", cfg.Provider)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return embedder, nil
+}
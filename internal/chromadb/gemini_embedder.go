@@ -0,0 +1,105 @@
+package chromadb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+// DefaultGeminiEmbeddingModel is used when no embedding model is configured
+const DefaultGeminiEmbeddingModel = "text-embedding-004"
+
+// GeminiEmbedder computes embeddings with the Gemini embedding API
+type GeminiEmbedder struct {
+	client     *genai.Client
+	modelName  string
+	docModel   *genai.EmbeddingModel
+	queryModel *genai.EmbeddingModel
+}
+
+// NewGeminiEmbedder creates a new Gemini embedder
+func NewGeminiEmbedder(ctx context.Context, apiKey, modelName string) (*GeminiEmbedder, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("Gemini API key is required for Gemini embeddings")
+	}
+	if modelName == "" {
+		modelName = DefaultGeminiEmbeddingModel
+	}
+
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+
+	// Documents and queries use different task types so retrieval is asymmetric
+	docModel := client.EmbeddingModel(modelName)
+	docModel.TaskType = genai.TaskTypeRetrievalDocument
+
+	queryModel := client.EmbeddingModel(modelName)
+	queryModel.TaskType = genai.TaskTypeRetrievalQuery
+
+	return &GeminiEmbedder{
+		client:     client,
+		modelName:  modelName,
+		docModel:   docModel,
+		queryModel: queryModel,
+	}, nil
+}
+
+// Close closes the underlying Gemini client
+func (e *GeminiEmbedder) Close() error {
+	return e.client.Close()
+}
+
+// Identity returns the provider and model name
+func (e *GeminiEmbedder) Identity() string {
+	return EmbedderGemini + ":" + e.modelName
+}
+
+// EmbedDocuments embeds all texts in a single batch request
+func (e *GeminiEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	batch := e.docModel.NewBatch()
+	for _, text := range texts {
+		batch.AddContent(genai.Text(text))
+	}
+
+	resp, err := e.docModel.BatchEmbedContents(ctx, batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed documents: %w", err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+	}
+
+	vectors := make([][]float64, len(resp.Embeddings))
+	for i, emb := range resp.Embeddings {
+		vectors[i] = toFloat64(emb.Values)
+	}
+	return vectors, nil
+}
+
+// EmbedQuery embeds a search query
+func (e *GeminiEmbedder) EmbedQuery(ctx context.Context, text string) ([]float64, error) {
+	resp, err := e.queryModel.EmbedContent(ctx, genai.Text(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if resp.Embedding == nil {
+		return nil, fmt.Errorf("no embedding in Gemini response")
+	}
+	return toFloat64(resp.Embedding.Values), nil
+}
+
+func toFloat64(values []float32) []float64 {
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = float64(v)
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package chromadb
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// defaultHashingDimensions is the vector size used by the local embedder
+const defaultHashingDimensions = 512
+
+// stopWords are dropped before hashing; they carry no topical signal
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"by": true, "for": true, "from": true, "in": true, "is": true, "it": true, "of": true,
+	"on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "with": true,
+}
+
+// HashingEmbedder is a deterministic, offline embedder. It hashes words and word
+// bigrams into a fixed number of buckets (the hashing trick), weights them by
+// sublinear term frequency and L2-normalises the result, so cosine similarity
+// reflects shared vocabulary. It needs no network access or model files.
+type HashingEmbedder struct {
+	dims int
+}
+
+// NewHashingEmbedder creates a local embedder; dims <= 0 selects the default size
+func NewHashingEmbedder(dims int) *HashingEmbedder {
+	if dims <= 0 {
+		dims = defaultHashingDimensions
+	}
+	return &HashingEmbedder{dims: dims}
+}
+
+// Close is a no-op
+func (e *HashingEmbedder) Close() error {
+	return nil
+}
+
+// Identity returns the embedder name and vector size
+func (e *HashingEmbedder) Identity() string {
+	return fmt.Sprintf("%s:hashing-%d", EmbedderLocal, e.dims)
+}
+
+// EmbedDocuments embeds each text independently
+func (e *HashingEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+// EmbedQuery embeds a search query
+func (e *HashingEmbedder) EmbedQuery(ctx context.Context, text string) ([]float64, error) {
+	return e.embed(text), nil
+}
+
+func (e *HashingEmbedder) embed(text string) []float64 {
+	tokens := tokenize(text)
+
+	counts := make(map[string]int)
+	for i, token := range tokens {
+		counts[token]++
+		if i > 0 {
+			counts[tokens[i-1]+" "+token]++
+		}
+	}
+
+	vector := make([]float64, e.dims)
+	for term, count := range counts {
+		h := fnv.New64a()
+		h.Write([]byte(term))
+		sum := h.Sum64()
+
+		// The top bit chooses the sign so colliding terms tend to cancel rather than add up
+		sign := 1.0
+		if sum>>63 == 1 {
+			sign = -1.0
+		}
+		vector[sum%uint64(e.dims)] += sign * (1 + math.Log(float64(count)))
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i] /= norm
+		}
+	}
+	return vector
+}
+
+// tokenize lower-cases text and splits it into words, dropping stop words
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if !stopWords[word] {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
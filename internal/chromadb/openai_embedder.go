@@ -0,0 +1,123 @@
+package chromadb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIEmbedder computes embeddings with any OpenAI-compatible /v1/embeddings endpoint
+type OpenAIEmbedder struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOpenAIEmbedder creates a new OpenAI-compatible embedder. baseURL should include
+// the version prefix, e.g. http://localhost:8081/v1. apiKey may be empty for local servers.
+func NewOpenAIEmbedder(baseURL, apiKey, model string) (*OpenAIEmbedder, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("OpenAI-compatible embeddings base URL is required")
+	}
+	if model == "" {
+		return nil, fmt.Errorf("model name is required for the OpenAI-compatible embedder")
+	}
+
+	return &OpenAIEmbedder{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}, nil
+}
+
+// Close is a no-op; the HTTP client holds no resources that need releasing
+func (e *OpenAIEmbedder) Close() error {
+	return nil
+}
+
+// Identity returns the provider and model name
+func (e *OpenAIEmbedder) Identity() string {
+	return EmbedderOpenAI + ":" + e.model
+}
+
+// EmbedDocuments embeds all texts in a single request
+func (e *OpenAIEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	return e.embed(ctx, texts)
+}
+
+// EmbedQuery embeds a search query
+func (e *OpenAIEmbedder) EmbedQuery(ctx context.Context, text string) ([]float64, error) {
+	vectors, err := e.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+func (e *OpenAIEmbedder) embed(ctx context.Context, texts []string) ([][]float64, error) {
+	reqBody := map[string]interface{}{
+		"model": e.model,
+		"input": texts,
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.baseURL+"/embeddings", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("HTTP %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse embeddings response: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(result.Data))
+	}
+
+	// The API may return items out of order; place them by index
+	vectors := make([][]float64, len(texts))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
@@ -0,0 +1,44 @@
+package chromadb
+
+import "slices"
+
+// Fields that can be requested in QueryOptions.Include
+const (
+	IncludeDocuments = "documents"
+	IncludeMetadatas = "metadatas"
+	IncludeDistances = "distances"
+)
+
+// QueryOptions describes a similarity search
+type QueryOptions struct {
+	QueryText string
+	NResults  int
+
+	// Collection to search; empty means the client's own collection
+	Collection string
+
+	// Where filters on metadata, e.g. {"category": "cv_evaluation"} or
+	// {"$and": [{"category": "scoring"}, {"version": {"$gte": 2}}]}
+	Where map[string]interface{}
+
+	// WhereDocument filters on document content, e.g. {"$contains": "rubric"}
+	WhereDocument map[string]interface{}
+
+	// MaxDistance drops matches farther than this from the query; 0 keeps every match
+	MaxDistance float64
+
+	// Include lists the fields to return; empty means documents, metadatas and distances
+	Include []string
+}
+
+// include returns the fields to request, adding distances when a cutoff needs them
+func (o QueryOptions) include() []string {
+	if len(o.Include) == 0 {
+		return []string{IncludeDocuments, IncludeMetadatas, IncludeDistances}
+	}
+	include := slices.Clone(o.Include)
+	if o.MaxDistance > 0 && !slices.Contains(include, IncludeDistances) {
+		include = append(include, IncludeDistances)
+	}
+	return include
+}
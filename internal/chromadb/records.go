@@ -0,0 +1,160 @@
+package chromadb
+
+import (
+	"context"
+	"fmt"
+)
+
+// maxEmbedBatch caps how many documents are embedded per request; Gemini rejects larger batches
+const maxEmbedBatch = 100
+
+// GetOptions selects documents by ID and/or filter. With no IDs or filters every document matches.
+type GetOptions struct {
+	IDs           []string
+	Where         map[string]interface{}
+	WhereDocument map[string]interface{}
+	Limit         int // 0 means no limit
+	Offset        int
+
+	// Include lists the fields to return; empty means documents and metadatas
+	Include []string
+}
+
+// DeleteOptions selects the documents to delete by ID and/or filter. At least one must be set.
+type DeleteOptions struct {
+	IDs           []string
+	Where         map[string]interface{}
+	WhereDocument map[string]interface{}
+}
+
+// Upsert embeds docs and inserts them, replacing any existing documents with the same IDs
+func (c *Client) Upsert(ctx context.Context, docs []Document) error {
+	for start := 0; start < len(docs); start += maxEmbedBatch {
+		batch := docs[start:min(start+maxEmbedBatch, len(docs))]
+
+		ids := make([]string, len(batch))
+		contents := make([]string, len(batch))
+		metadatas := make([]map[string]interface{}, len(batch))
+		for i, doc := range batch {
+			ids[i] = doc.ID
+			contents[i] = doc.Content
+			metadatas[i] = doc.Metadata
+		}
+
+		embeddings, err := c.embedder.EmbedDocuments(ctx, contents)
+		if err != nil {
+			return fmt.Errorf("failed to embed documents: %w", err)
+		}
+
+		reqBody := map[string]interface{}{
+			"ids":        ids,
+			"documents":  contents,
+			"metadatas":  metadatas,
+			"embeddings": embeddings,
+		}
+		if err := c.doCollection(ctx, "POST", "upsert", reqBody, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the documents matching opts
+func (c *Client) Get(ctx context.Context, opts GetOptions) ([]Document, error) {
+	include := opts.Include
+	if len(include) == 0 {
+		include = []string{IncludeDocuments, IncludeMetadatas}
+	}
+
+	reqBody := map[string]interface{}{
+		"include": include,
+	}
+	if len(opts.IDs) > 0 {
+		reqBody["ids"] = opts.IDs
+	}
+	if len(opts.Where) > 0 {
+		reqBody["where"] = opts.Where
+	}
+	if len(opts.WhereDocument) > 0 {
+		reqBody["where_document"] = opts.WhereDocument
+	}
+	if opts.Limit > 0 {
+		reqBody["limit"] = opts.Limit
+	}
+	if opts.Offset > 0 {
+		reqBody["offset"] = opts.Offset
+	}
+
+	var result struct {
+		IDs       []string                 `json:"ids"`
+		Documents []*string                `json:"documents"`
+		Metadatas []map[string]interface{} `json:"metadatas"`
+	}
+	if err := c.doCollection(ctx, "POST", "get", reqBody, &result); err != nil {
+		return nil, err
+	}
+
+	documents := make([]Document, len(result.IDs))
+	for i, id := range result.IDs {
+		documents[i].ID = id
+		if i < len(result.Documents) && result.Documents[i] != nil {
+			documents[i].Content = *result.Documents[i]
+		}
+		if i < len(result.Metadatas) {
+			documents[i].Metadata = result.Metadatas[i]
+		}
+	}
+	return documents, nil
+}
+
+// Delete removes the documents matching opts
+func (c *Client) Delete(ctx context.Context, opts DeleteOptions) error {
+	if len(opts.IDs) == 0 && len(opts.Where) == 0 && len(opts.WhereDocument) == 0 {
+		return fmt.Errorf("delete requires IDs or a filter")
+	}
+
+	reqBody := map[string]interface{}{}
+	if len(opts.IDs) > 0 {
+		reqBody["ids"] = opts.IDs
+	}
+	if len(opts.Where) > 0 {
+		reqBody["where"] = opts.Where
+	}
+	if len(opts.WhereDocument) > 0 {
+		reqBody["where_document"] = opts.WhereDocument
+	}
+
+	return c.doCollection(ctx, "POST", "delete", reqBody, nil)
+}
+
+// Count returns the number of documents in the collection
+func (c *Client) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := c.doCollection(ctx, "GET", "count", nil, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Peek returns up to limit documents from the start of the collection
+func (c *Client) Peek(ctx context.Context, limit int) ([]Document, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	return c.Get(ctx, GetOptions{Limit: limit})
+}
+
+// doCollection sends a request to an endpoint of the initialized collection
+func (c *Client) doCollection(ctx context.Context, method, operation string, body, out interface{}) error {
+	collectionID, err := c.initializedCollectionID()
+	if err != nil {
+		return err
+	}
+	return c.doCollectionID(ctx, method, collectionID, operation, body, out)
+}
+
+// doCollectionID sends a request to an endpoint of the collection with the given UUID
+func (c *Client) doCollectionID(ctx context.Context, method, collectionID, operation string, body, out interface{}) error {
+	endpoint := fmt.Sprintf("/api/v2/tenants/%s/databases/%s/collections/%s/%s", c.tenant, c.database, collectionID, operation)
+	return c.doJSON(ctx, method, endpoint, body, out)
+}
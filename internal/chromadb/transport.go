@@ -0,0 +1,194 @@
+package chromadb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// Headers that can carry the ChromaDB auth token
+const (
+	AuthHeaderAuthorization = "Authorization" // sent as "Bearer <token>"
+	AuthHeaderChromaToken   = "X-Chroma-Token"
+)
+
+// RetryConfig controls how requests failing with 5xx, 429 or connection errors are retried
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Defaults used when no retry or breaker option is given
+var (
+	DefaultRetryConfig = RetryConfig{MaxAttempts: 3, BaseDelay: 200 * time.Millisecond, MaxDelay: 2 * time.Second}
+
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// WithAuthToken authenticates every request with token, sent in header
+// (AuthHeaderAuthorization or AuthHeaderChromaToken)
+func WithAuthToken(header, token string) Option {
+	return func(c *Client) {
+		if header == "" {
+			header = AuthHeaderAuthorization
+		}
+		c.authHeader, c.authToken = http.CanonicalHeaderKey(header), token
+	}
+}
+
+// WithTimeout sets the timeout of a single HTTP request
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if timeout > 0 {
+			c.httpClient.Timeout = timeout
+		}
+	}
+}
+
+// WithRetry sets the retry policy; MaxAttempts of 1 disables retries
+func WithRetry(retry RetryConfig) Option {
+	return func(c *Client) {
+		if retry.MaxAttempts > 0 {
+			c.retry = retry
+		}
+	}
+}
+
+// WithCircuitBreaker opens the breaker after threshold consecutive failed requests, failing fast
+// for cooldown before probing ChromaDB again. A threshold of 0 disables the breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.breaker = newCircuitBreaker(threshold, cooldown)
+	}
+}
+
+// CircuitStatus reports the state of the client's circuit breaker
+func (c *Client) CircuitStatus() CircuitStatus {
+	return c.breaker.status()
+}
+
+// HTTPError is returned when ChromaDB answers with an error status
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d - %s", e.StatusCode, e.Body)
+}
+
+// isNotFound reports whether err is a 404 from ChromaDB
+func isNotFound(err error) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
+
+// doJSON sends body as JSON, if set, and decodes the response into out, if set. Transient
+// failures are retried, and the outcome is recorded by the circuit breaker.
+func (c *Client) doJSON(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+
+	var err error
+	var retryable bool
+	for attempt := 1; ; attempt++ {
+		retryable, err = c.send(ctx, method, endpoint, payload, out)
+		if err == nil || !retryable || attempt >= c.retry.MaxAttempts {
+			break
+		}
+
+		delay := c.retry.backoff(attempt)
+		log.Printf("ChromaDB %s %s failed, retrying in %s: %v", method, endpoint, delay, err)
+
+		select {
+		case <-ctx.Done():
+			c.breaker.abandon()
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		c.breaker.abandon()
+	case err != nil && retryable:
+		c.breaker.failure(err)
+	default:
+		// Client errors such as 404 still prove the server is healthy
+		c.breaker.success()
+	}
+	return err
+}
+
+// send makes a single request and reports whether a failure is worth retrying
+func (c *Client) send(ctx context.Context, method, endpoint string, payload []byte, out interface{}) (retryable bool, err error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, reqBody)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.authToken != "" {
+		if c.authHeader == AuthHeaderAuthorization {
+			req.Header.Set(AuthHeaderAuthorization, "Bearer "+c.authToken)
+		} else {
+			req.Header.Set(c.authHeader, c.authToken)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(resp.Body)
+		retryable = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, &HTTPError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	if out == nil {
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return false, nil
+}
+
+// backoff returns the delay before the given retry using exponential backoff with full jitter
+func (r RetryConfig) backoff(attempt int) time.Duration {
+	delay := r.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(delay) + 1))
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -24,17 +26,121 @@ type DBConfig struct {
 	ConnectionIdle     time.Duration
 }
 
+type QueueConfig struct {
+	Workers           int
+	MaxDepth          int
+	PollInterval      time.Duration
+	LeaseDuration     time.Duration
+	HeartbeatInterval time.Duration
+	MaxAttempts       int
+}
+
+// UploadConfig limits the files accepted by the evaluate endpoint
+type UploadConfig struct {
+	MaxFileSize   int64 // bytes
+	MaxPages      int
+	MinTextLength int // characters of extracted text
+}
+
+// StorageConfig selects where uploaded files are kept
+type StorageConfig struct {
+	Backend   string // "local" or "s3"
+	LocalRoot string
+
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3PathStyle    bool
+	S3CreateBucket bool
+}
+
+// RetentionConfig controls how long candidate data is kept; a zero period keeps it forever
+type RetentionConfig struct {
+	Files         time.Duration // uploaded CVs and reports
+	Results       time.Duration // evaluation results and analyses
+	PurgeInterval time.Duration
+}
+
+// RedactionConfig selects the personal information masked before documents are sent to the LLM
+type RedactionConfig struct {
+	Types     []string // e.g. email, phone, url, address, name; empty disables redaction
+	NamesFile string   // optional list of names to mask, one per line
+}
+
+type LLMConfig struct {
+	Provider       string
+	Model          string
+	BaseURL        string
+	APIKey         string
+	FakeScript     string
+	FakeFaults     string
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+type EmbeddingConfig struct {
+	Provider string
+	Model    string
+	BaseURL  string
+	APIKey   string
+}
+
+type RetrievalConfig struct {
+	TopK        int
+	MaxDistance float64
+
+	// Store is "chroma" or "embedded". With "chroma", the embedded store built from
+	// GuidelinesDir serves as a fallback while ChromaDB is unavailable.
+	Store         string
+	GuidelinesDir string
+	CachePath     string
+}
+
+type ChromaDBConfig struct {
+	Tenant     string
+	Database   string
+	Collection string
+
+	// Token sent in AuthHeader ("Authorization" as a bearer token, or "X-Chroma-Token")
+	AuthToken  string
+	AuthHeader string
+
+	Timeout          time.Duration
+	MaxAttempts      int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// TrackCollections maps a hiring track, e.g. "backend", to the collection holding its guidelines
+	TrackCollections map[string]string
+}
+
 type Config struct {
 	AppPort      string
 	DB           *DBConfig
+	Queue        *QueueConfig
+	Upload       *UploadConfig
+	Storage      *StorageConfig
+	Retention    *RetentionConfig
+	Redaction    *RedactionConfig
+	LLM          *LLMConfig
+	Embedding    *EmbeddingConfig
+	Retrieval    *RetrievalConfig
 	DatabaseURL  string
 	GeminiAPIKey string
 	ChromaDBURL  string
+	ChromaDB     *ChromaDBConfig
 }
 
 func LoadConfig() (*Config, error) {
+	// The .env file is optional so the server can also be configured purely
+	// through the environment, e.g. in CI or when running with the fake LLM provider
 	err := godotenv.Load()
-	if err != nil {
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return nil, fmt.Errorf("error loading .env file: %w", err)
 	}
 
@@ -86,6 +192,296 @@ func LoadConfig() (*Config, error) {
 		dbConfig.SSLMode,
 	)
 
+	// Parse job queue configuration
+	workers, err := strconv.Atoi(getEnvOrDefault("QUEUE_WORKERS", "2"))
+	if err != nil || workers < 1 {
+		return nil, fmt.Errorf("invalid QUEUE_WORKERS: must be a positive integer")
+	}
+
+	maxDepth, err := strconv.Atoi(getEnvOrDefault("QUEUE_MAX_DEPTH", "100"))
+	if err != nil || maxDepth < 0 {
+		return nil, fmt.Errorf("invalid QUEUE_MAX_DEPTH: must be a non-negative integer")
+	}
+
+	pollInterval, err := time.ParseDuration(getEnvOrDefault("QUEUE_POLL_INTERVAL", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid QUEUE_POLL_INTERVAL: %w", err)
+	}
+	if pollInterval <= 0 {
+		return nil, fmt.Errorf("invalid QUEUE_POLL_INTERVAL: must be positive")
+	}
+
+	leaseDuration, err := time.ParseDuration(getEnvOrDefault("QUEUE_LEASE_DURATION", "2m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid QUEUE_LEASE_DURATION: %w", err)
+	}
+	if leaseDuration <= 0 {
+		return nil, fmt.Errorf("invalid QUEUE_LEASE_DURATION: must be positive")
+	}
+
+	heartbeatInterval, err := time.ParseDuration(getEnvOrDefault("QUEUE_HEARTBEAT_INTERVAL", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid QUEUE_HEARTBEAT_INTERVAL: %w", err)
+	}
+	if heartbeatInterval <= 0 {
+		return nil, fmt.Errorf("invalid QUEUE_HEARTBEAT_INTERVAL: must be positive")
+	}
+	if heartbeatInterval >= leaseDuration {
+		return nil, fmt.Errorf("QUEUE_HEARTBEAT_INTERVAL must be shorter than QUEUE_LEASE_DURATION")
+	}
+
+	maxAttempts, err := strconv.Atoi(getEnvOrDefault("QUEUE_MAX_ATTEMPTS", "3"))
+	if err != nil || maxAttempts < 1 {
+		return nil, fmt.Errorf("invalid QUEUE_MAX_ATTEMPTS: must be a positive integer")
+	}
+
+	queueConfig := &QueueConfig{
+		Workers:           workers,
+		MaxDepth:          maxDepth,
+		PollInterval:      pollInterval,
+		LeaseDuration:     leaseDuration,
+		HeartbeatInterval: heartbeatInterval,
+		MaxAttempts:       maxAttempts,
+	}
+
+	// Parse upload limits
+	maxFileSize, err := strconv.ParseInt(getEnvOrDefault("UPLOAD_MAX_FILE_SIZE", "10485760"), 10, 64)
+	if err != nil || maxFileSize <= 0 {
+		return nil, fmt.Errorf("invalid UPLOAD_MAX_FILE_SIZE: must be a positive number of bytes")
+	}
+
+	maxPages, err := strconv.Atoi(getEnvOrDefault("UPLOAD_MAX_PAGES", "20"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid UPLOAD_MAX_PAGES: %w", err)
+	}
+
+	minTextLength, err := strconv.Atoi(getEnvOrDefault("UPLOAD_MIN_TEXT_LENGTH", "100"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid UPLOAD_MIN_TEXT_LENGTH: %w", err)
+	}
+
+	uploadConfig := &UploadConfig{
+		MaxFileSize:   maxFileSize,
+		MaxPages:      maxPages,
+		MinTextLength: minTextLength,
+	}
+
+	// Parse file storage configuration
+	storageBackend := getEnvOrDefault("STORAGE_BACKEND", "local")
+	if storageBackend != "local" && storageBackend != "s3" {
+		return nil, fmt.Errorf("invalid STORAGE_BACKEND: must be local or s3")
+	}
+
+	s3PathStyle, err := strconv.ParseBool(getEnvOrDefault("S3_PATH_STYLE", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3_PATH_STYLE: %w", err)
+	}
+
+	s3CreateBucket, err := strconv.ParseBool(getEnvOrDefault("S3_CREATE_BUCKET", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3_CREATE_BUCKET: %w", err)
+	}
+
+	storageConfig := &StorageConfig{
+		Backend:        storageBackend,
+		LocalRoot:      getEnvOrDefault("STORAGE_LOCAL_ROOT", "uploads"),
+		S3Endpoint:     os.Getenv("S3_ENDPOINT"),
+		S3Region:       getEnvOrDefault("S3_REGION", "us-east-1"),
+		S3Bucket:       os.Getenv("S3_BUCKET"),
+		S3AccessKey:    os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:    os.Getenv("S3_SECRET_KEY"),
+		S3PathStyle:    s3PathStyle,
+		S3CreateBucket: s3CreateBucket,
+	}
+
+	// Parse data retention configuration
+	fileRetention, err := parseRetention(getEnvOrDefault("RETENTION_FILES", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RETENTION_FILES: %w", err)
+	}
+
+	resultRetention, err := parseRetention(getEnvOrDefault("RETENTION_RESULTS", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RETENTION_RESULTS: %w", err)
+	}
+
+	purgeInterval, err := time.ParseDuration(getEnvOrDefault("RETENTION_PURGE_INTERVAL", "1h"))
+	if err != nil || purgeInterval <= 0 {
+		return nil, fmt.Errorf("invalid RETENTION_PURGE_INTERVAL: must be a positive duration")
+	}
+
+	retentionConfig := &RetentionConfig{
+		Files:         fileRetention,
+		Results:       resultRetention,
+		PurgeInterval: purgeInterval,
+	}
+
+	// Parse PII redaction configuration
+	var redactTypes []string
+	if value := getEnvOrDefault("PII_REDACTION", "email,phone,address,name"); value != "none" {
+		for _, t := range strings.Split(value, ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				redactTypes = append(redactTypes, t)
+			}
+		}
+	}
+
+	redactionConfig := &RedactionConfig{
+		Types:     redactTypes,
+		NamesFile: os.Getenv("PII_NAMES_FILE"),
+	}
+
+	// Parse LLM provider configuration
+	llmProvider := getEnvOrDefault("LLM_PROVIDER", "gemini")
+	llmAPIKey := os.Getenv("GEMINI_API_KEY")
+	if llmProvider == "openai" {
+		llmAPIKey = os.Getenv("OPENAI_API_KEY")
+	}
+
+	llmMaxAttempts, err := strconv.Atoi(getEnvOrDefault("LLM_MAX_ATTEMPTS", "3"))
+	if err != nil || llmMaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid LLM_MAX_ATTEMPTS: must be a positive integer")
+	}
+
+	retryBaseDelay, err := time.ParseDuration(getEnvOrDefault("LLM_RETRY_BASE_DELAY", "2s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_RETRY_BASE_DELAY: %w", err)
+	}
+	if retryBaseDelay < 0 {
+		return nil, fmt.Errorf("invalid LLM_RETRY_BASE_DELAY: must not be negative")
+	}
+
+	retryMaxDelay, err := time.ParseDuration(getEnvOrDefault("LLM_RETRY_MAX_DELAY", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_RETRY_MAX_DELAY: %w", err)
+	}
+	if retryMaxDelay < 0 {
+		return nil, fmt.Errorf("invalid LLM_RETRY_MAX_DELAY: must not be negative")
+	}
+
+	llmConfig := &LLMConfig{
+		Provider:       llmProvider,
+		Model:          os.Getenv("LLM_MODEL"),
+		BaseURL:        os.Getenv("OPENAI_BASE_URL"),
+		APIKey:         llmAPIKey,
+		FakeScript:     os.Getenv("LLM_FAKE_SCRIPT"),
+		FakeFaults:     os.Getenv("LLM_FAKE_FAULTS"),
+		MaxAttempts:    llmMaxAttempts,
+		RetryBaseDelay: retryBaseDelay,
+		RetryMaxDelay:  retryMaxDelay,
+	}
+
+	// Parse embedding configuration. API keys fall back to the ones used for the LLM.
+	embeddingProvider := getEnvOrDefault("EMBEDDING_PROVIDER", "local")
+	embeddingAPIKey := os.Getenv("EMBEDDING_API_KEY")
+	if embeddingAPIKey == "" {
+		switch embeddingProvider {
+		case "gemini":
+			embeddingAPIKey = os.Getenv("GEMINI_API_KEY")
+		case "openai":
+			embeddingAPIKey = os.Getenv("OPENAI_API_KEY")
+		}
+	}
+
+	embeddingConfig := &EmbeddingConfig{
+		Provider: embeddingProvider,
+		Model:    os.Getenv("EMBEDDING_MODEL"),
+		BaseURL:  getEnvOrDefault("EMBEDDING_BASE_URL", os.Getenv("OPENAI_BASE_URL")),
+		APIKey:   embeddingAPIKey,
+	}
+
+	// Parse retrieval configuration
+	topK, err := strconv.Atoi(getEnvOrDefault("RETRIEVAL_TOP_K", "2"))
+	if err != nil || topK < 1 {
+		return nil, fmt.Errorf("invalid RETRIEVAL_TOP_K: must be a positive integer")
+	}
+
+	maxDistance, err := strconv.ParseFloat(getEnvOrDefault("RETRIEVAL_MAX_DISTANCE", "0"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RETRIEVAL_MAX_DISTANCE: %w", err)
+	}
+
+	vectorStore := getEnvOrDefault("VECTOR_STORE", "chroma")
+	if vectorStore != "chroma" && vectorStore != "embedded" {
+		return nil, fmt.Errorf("invalid VECTOR_STORE: must be chroma or embedded")
+	}
+
+	retrievalConfig := &RetrievalConfig{
+		TopK:          topK,
+		MaxDistance:   maxDistance,
+		Store:         vectorStore,
+		GuidelinesDir: getEnvOrDefault("GUIDELINES_DIR", "data/evaluation_guidelines"),
+		CachePath:     os.Getenv("VECTOR_STORE_CACHE"),
+	}
+
+	// Parse ChromaDB namespace configuration
+	trackCollections, err := parseTrackCollections(os.Getenv("CHROMADB_TRACK_COLLECTIONS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CHROMADB_TRACK_COLLECTIONS: %w", err)
+	}
+
+	authHeader := getEnvOrDefault("CHROMADB_AUTH_HEADER", "Authorization")
+	if !strings.EqualFold(authHeader, "Authorization") && !strings.EqualFold(authHeader, "X-Chroma-Token") {
+		return nil, fmt.Errorf("invalid CHROMADB_AUTH_HEADER: must be Authorization or X-Chroma-Token")
+	}
+
+	chromaTimeout, err := time.ParseDuration(getEnvOrDefault("CHROMADB_TIMEOUT", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CHROMADB_TIMEOUT: %w", err)
+	}
+	if chromaTimeout <= 0 {
+		return nil, fmt.Errorf("invalid CHROMADB_TIMEOUT: must be positive")
+	}
+
+	chromaMaxAttempts, err := strconv.Atoi(getEnvOrDefault("CHROMADB_MAX_ATTEMPTS", "3"))
+	if err != nil || chromaMaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid CHROMADB_MAX_ATTEMPTS: must be a positive integer")
+	}
+
+	chromaRetryBaseDelay, err := time.ParseDuration(getEnvOrDefault("CHROMADB_RETRY_BASE_DELAY", "200ms"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CHROMADB_RETRY_BASE_DELAY: %w", err)
+	}
+	if chromaRetryBaseDelay < 0 {
+		return nil, fmt.Errorf("invalid CHROMADB_RETRY_BASE_DELAY: must not be negative")
+	}
+
+	chromaRetryMaxDelay, err := time.ParseDuration(getEnvOrDefault("CHROMADB_RETRY_MAX_DELAY", "2s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CHROMADB_RETRY_MAX_DELAY: %w", err)
+	}
+	if chromaRetryMaxDelay < 0 {
+		return nil, fmt.Errorf("invalid CHROMADB_RETRY_MAX_DELAY: must not be negative")
+	}
+
+	breakerThreshold, err := strconv.Atoi(getEnvOrDefault("CHROMADB_BREAKER_THRESHOLD", "5"))
+	if err != nil || breakerThreshold < 1 {
+		return nil, fmt.Errorf("invalid CHROMADB_BREAKER_THRESHOLD: must be a positive integer")
+	}
+
+	breakerCooldown, err := time.ParseDuration(getEnvOrDefault("CHROMADB_BREAKER_COOLDOWN", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CHROMADB_BREAKER_COOLDOWN: %w", err)
+	}
+	if breakerCooldown <= 0 {
+		return nil, fmt.Errorf("invalid CHROMADB_BREAKER_COOLDOWN: must be positive")
+	}
+
+	chromaConfig := &ChromaDBConfig{
+		Tenant:           getEnvOrDefault("CHROMADB_TENANT", "default_tenant"),
+		Database:         getEnvOrDefault("CHROMADB_DATABASE", "default_database"),
+		Collection:       getEnvOrDefault("CHROMADB_COLLECTION", "evaluation_guidelines"),
+		TrackCollections: trackCollections,
+		AuthToken:        os.Getenv("CHROMADB_AUTH_TOKEN"),
+		AuthHeader:       authHeader,
+		Timeout:          chromaTimeout,
+		MaxAttempts:      chromaMaxAttempts,
+		RetryBaseDelay:   chromaRetryBaseDelay,
+		RetryMaxDelay:    chromaRetryMaxDelay,
+		BreakerThreshold: breakerThreshold,
+		BreakerCooldown:  breakerCooldown,
+	}
+
 	appPort := getEnvOrDefault("APP_PORT", "8080")
 	// Ensure port has colon prefix for Fiber
 	if appPort[0] != ':' {
@@ -95,12 +491,63 @@ func LoadConfig() (*Config, error) {
 	return &Config{
 		AppPort:      appPort,
 		DB:           dbConfig,
+		Queue:        queueConfig,
+		Upload:       uploadConfig,
+		Storage:      storageConfig,
+		Retention:    retentionConfig,
+		Redaction:    redactionConfig,
+		LLM:          llmConfig,
+		Embedding:    embeddingConfig,
+		Retrieval:    retrievalConfig,
 		DatabaseURL:  dbURL,
 		GeminiAPIKey: os.Getenv("GEMINI_API_KEY"),
 		ChromaDBURL:  getEnvOrDefault("CHROMADB_URL", "http://localhost:8000"),
+		ChromaDB:     chromaConfig,
 	}, nil
 }
 
+// parseRetention parses a retention period given as a number of days such as "30d", or as a
+// Go duration such as "720h"
+func parseRetention(value string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days %q", days)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(value); err != nil {
+			return 0, err
+		}
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("retention period must not be negative")
+	}
+	return d, nil
+}
+
+// parseTrackCollections parses "track=collection,track=collection" pairs
+func parseTrackCollections(value string) (map[string]string, error) {
+	tracks := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		track, collection, ok := strings.Cut(pair, "=")
+		track, collection = strings.TrimSpace(track), strings.TrimSpace(collection)
+		if !ok || track == "" || collection == "" {
+			return nil, fmt.Errorf("expected track=collection, got %q", pair)
+		}
+		tracks[strings.ToLower(track)] = collection
+	}
+	return tracks, nil
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
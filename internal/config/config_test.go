@@ -0,0 +1,61 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigRejectsInvalidQueueSettings(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "fake")
+	if _, err := LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig() with defaults error = %v", err)
+	}
+
+	tests := []struct {
+		env   map[string]string
+		field string
+	}{
+		{map[string]string{"QUEUE_WORKERS": "0"}, "QUEUE_WORKERS"},
+		{map[string]string{"QUEUE_MAX_DEPTH": "-1"}, "QUEUE_MAX_DEPTH"},
+		{map[string]string{"QUEUE_POLL_INTERVAL": "0s"}, "QUEUE_POLL_INTERVAL"},
+		{map[string]string{"QUEUE_LEASE_DURATION": "-1m", "QUEUE_HEARTBEAT_INTERVAL": "-2m"}, "QUEUE_LEASE_DURATION"},
+		{map[string]string{"QUEUE_HEARTBEAT_INTERVAL": "0s"}, "QUEUE_HEARTBEAT_INTERVAL"},
+		{map[string]string{"QUEUE_HEARTBEAT_INTERVAL": "2m"}, "QUEUE_HEARTBEAT_INTERVAL"},
+		{map[string]string{"QUEUE_MAX_ATTEMPTS": "0"}, "QUEUE_MAX_ATTEMPTS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			_, err := LoadConfig()
+			if err == nil || !strings.Contains(err.Error(), tt.field) {
+				t.Errorf("LoadConfig() with %v error = %v, want one naming %s", tt.env, err, tt.field)
+			}
+		})
+	}
+}
+
+func TestLoadConfigRejectsInvalidChromaSettings(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "fake")
+
+	tests := map[string]string{
+		"CHROMADB_TIMEOUT":           "0s",
+		"CHROMADB_MAX_ATTEMPTS":      "0",
+		"CHROMADB_RETRY_BASE_DELAY":  "-1s",
+		"CHROMADB_RETRY_MAX_DELAY":   "-1s",
+		"CHROMADB_BREAKER_THRESHOLD": "-5",
+		"CHROMADB_BREAKER_COOLDOWN":  "0s",
+	}
+
+	for key, value := range tests {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			_, err := LoadConfig()
+			if err == nil || !strings.Contains(err.Error(), key) {
+				t.Errorf("LoadConfig() with %s=%s error = %v, want one naming it", key, value, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package domain
+
+// Actions recorded in the deletion audit log
+const (
+	DeletionFilesDeleted      = "files_deleted"      // an evaluation's uploaded files were deleted
+	DeletionResultRedacted    = "result_redacted"    // an evaluation's result and candidate data were cleared
+	DeletionEvaluationDeleted = "evaluation_deleted" // an evaluation was deleted entirely
+	DeletionDocumentDeleted   = "document_deleted"   // a stored file and its cached text were deleted
+)
+
+// Reasons recorded in the deletion audit log
+const (
+	DeletionReasonRetention = "retention" // the retention period expired
+	DeletionReasonRequest   = "request"   // deletion was requested through the API
+)
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Document is an uploaded file, stored once per distinct content
+type Document struct {
+	ID         uuid.UUID `db:"id"`
+	SHA256     string    `db:"sha256"`      // hex digest of the file content
+	StorageKey string    `db:"storage_key"` // derived from SHA256
+	Size       int64     `db:"size"`
+	MIMEType   string    `db:"mime_type"`
+
+	// Extracted caches the text extracted from the file as a JSON util.Document
+	Extracted *json.RawMessage `db:"extracted"`
+
+	CreatedAt  time.Time `db:"created_at"`
+	LastUsedAt time.Time `db:"last_used_at"` // last upload of this content
+}
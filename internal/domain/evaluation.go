@@ -14,17 +14,57 @@ const (
 	StatusProcessing EvaluationStatus = "processing"
 	StatusCompleted  EvaluationStatus = "completed"
 	StatusFailed     EvaluationStatus = "failed"
+	StatusCancelled  EvaluationStatus = "cancelled"
 )
 
+// ErrorCodeMaxAttempts is set when a job is abandoned after its worker repeatedly stopped heartbeating
+const ErrorCodeMaxAttempts = "max_attempts_exceeded"
+
 // Evaluation represents the core domain model
 type Evaluation struct {
-	ID         uuid.UUID        `db:"id"`
-	Status     EvaluationStatus `db:"status"`
-	CVPath     string           `db:"cv_path"`
-	ReportPath string           `db:"report_path"`
-	Result     *json.RawMessage `db:"result"`
-	CreatedAt  time.Time        `db:"created_at"`
-	UpdatedAt  time.Time        `db:"updated_at"`
+	ID        uuid.UUID        `db:"id"`
+	Status    EvaluationStatus `db:"status"`
+	CVKey     string           `db:"cv_key"`     // storage key of the uploaded CV
+	ReportKey string           `db:"report_key"` // storage key of the uploaded project report
+
+	// Documents the uploaded files are recorded as; nil for evaluations created before
+	// uploads were deduplicated
+	CVDocumentID     *uuid.UUID `db:"cv_document_id"`
+	ReportDocumentID *uuid.UUID `db:"report_document_id"`
+
+	// Job requirements the CV is matched against. JobDescription is a snapshot of the
+	// text at submission time, so later edits do not change queued evaluations.
+	JobDescriptionID *uuid.UUID `db:"job_description_id"`
+	JobDescription   *string    `db:"job_description"`
+
+	// Hiring track and the knowledge base collection its guidelines are retrieved from;
+	// nil means the default collection
+	Track      *string `db:"track"`
+	Collection *string `db:"collection"`
+
+	Result    *json.RawMessage `db:"result"`
+	Analysis  *json.RawMessage `db:"analysis"` // Stage 1 analysis
+	Metadata  *json.RawMessage `db:"metadata"` // how the evaluation was run, e.g. what was redacted
+	CreatedAt time.Time        `db:"created_at"`
+	UpdatedAt time.Time        `db:"updated_at"`
+
+	// Queue lease bookkeeping
+	Attempts       int        `db:"attempts"`
+	LeaseOwner     *string    `db:"lease_owner"`
+	LeaseExpiresAt *time.Time `db:"lease_expires_at"`
+
+	// Failure details, set when Status is failed
+	ErrorCode    *string `db:"error_code"`
+	ErrorMessage *string `db:"error_message"`
+	FailedStage  *string `db:"failed_stage"`
+
+	// LLMAttempts is the JSON array of LLM call attempts made for this evaluation
+	LLMAttempts *json.RawMessage `db:"llm_attempts"`
+
+	// Retention bookkeeping: when the uploaded files were deleted, and when the result,
+	// analysis and other candidate data were cleared from this row
+	FilesDeletedAt *time.Time `db:"files_deleted_at"`
+	RedactedAt     *time.Time `db:"redacted_at"`
 }
 
 // Struct for the final result format
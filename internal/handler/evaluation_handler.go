@@ -1,21 +1,33 @@
 package handler
 
 import (
+	"aicvevaluator/internal/domain"
 	"aicvevaluator/internal/service"
+	"aicvevaluator/internal/util"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"path/filepath"
+	"mime/multipart"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
+// queueFullRetryAfter is the Retry-After hint (in seconds) sent when the queue is full
+const queueFullRetryAfter = 30
+
 type EvaluationHandler struct {
-	service service.EvaluationService
+	service   service.EvaluationService
+	validator *service.UploadValidator
+	documents service.DocumentService
+	retention *service.RetentionService
 }
 
-func NewEvaluationHandler(s service.EvaluationService) *EvaluationHandler {
-	return &EvaluationHandler{service: s}
+func NewEvaluationHandler(s service.EvaluationService, validator *service.UploadValidator, documents service.DocumentService, retention *service.RetentionService) *EvaluationHandler {
+	return &EvaluationHandler{service: s, validator: validator, documents: documents, retention: retention}
 }
 
 func (h *EvaluationHandler) Evaluate(c *fiber.Ctx) error {
@@ -29,27 +41,53 @@ func (h *EvaluationHandler) Evaluate(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Project report file is required"})
 	}
 
-	cvFilename := fmt.Sprintf("%s-%s", uuid.New().String(), filepath.Base(cvFile.Filename))
-	reportFilename := fmt.Sprintf("%s-%s", uuid.New().String(), filepath.Base(reportFile.Filename))
+	input := service.CreateEvaluationInput{
+		JobDescription: c.FormValue("job_description"),
+		Track:          c.FormValue("track"),
+	}
+	if rawID := c.FormValue("job_description_id"); rawID != "" {
+		jdID, err := uuid.Parse(rawID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid job_description_id format"})
+		}
+		input.JobDescriptionID = &jdID
+	}
 
-	cvPath := filepath.Join("uploads", cvFilename)
-	reportPath := filepath.Join("uploads", reportFilename)
+	// Check everything but the files first, so a rejected request stores nothing
+	if err := h.service.CheckEvaluation(c.Context(), input); err != nil {
+		return createError(c, input, err)
+	}
 
-	if err := c.SaveFile(cvFile, cvPath); err != nil {
-		log.Printf("Error saving CV file: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save CV file"})
+	// Reject unreadable documents now rather than failing the evaluation later
+	uploads := []*upload{{field: "cv", file: cvFile}, {field: "project_report", file: reportFile}}
+	for _, upload := range uploads {
+		if err := h.validateUpload(upload); err != nil {
+			var uploadErr *service.UploadError
+			if errors.As(err, &uploadErr) {
+				return c.Status(uploadErrorStatus(uploadErr.Code)).JSON(uploadErr)
+			}
+			log.Printf("Error reading uploaded %s: %v", upload.field, err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("could not read %s file", upload.field)})
+		}
 	}
-	if err := c.SaveFile(reportFile, reportPath); err != nil {
-		log.Printf("Error saving report file: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save report file"})
+
+	// Files are stored by content; a file uploaded before is not stored again. Documents
+	// are kept if the evaluation is still not created, as other evaluations may share them;
+	// the retention janitor deletes them once no evaluation has used them for an hour.
+	stored := make([]*domain.Document, 0, len(uploads))
+	for _, upload := range uploads {
+		doc, err := h.documents.Store(c.Context(), upload.data, upload.doc)
+		if err != nil {
+			log.Printf("Error saving %s file: %v", upload.field, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": fmt.Sprintf("failed to save %s file", upload.field)})
+		}
+		stored = append(stored, doc)
 	}
+	input.CVDocument, input.ReportDocument = stored[0], stored[1]
 
-	eval, err := h.service.CreateEvaluation(c.Context(), cvPath, reportPath)
+	eval, err := h.service.CreateEvaluation(c.Context(), input)
 	if err != nil {
-		log.Printf("Error creating evaluation task: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "could not create evaluation task",
-		})
+		return createError(c, input, err)
 	}
 
 	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
@@ -58,6 +96,73 @@ func (h *EvaluationHandler) Evaluate(c *fiber.Ctx) error {
 	})
 }
 
+// createError responds to a request whose evaluation could not be created
+func createError(c *fiber.Ctx, input service.CreateEvaluationInput, err error) error {
+	switch {
+	case errors.Is(err, service.ErrJobDescriptionNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrConflictingJobDescription), errors.Is(err, service.ErrInvalidJobDescription):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrUnknownTrack):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("unknown track %q", input.Track)})
+	}
+
+	if errors.Is(err, service.ErrQueueFull) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(queueFullRetryAfter))
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "evaluation queue is full, please retry later",
+		})
+	}
+
+	log.Printf("Error creating evaluation task: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "could not create evaluation task",
+	})
+}
+
+// upload is a file submitted with an evaluation, read into memory once it passes validation
+type upload struct {
+	field string
+	file  *multipart.FileHeader
+	data  []byte
+	doc   *util.Document
+}
+
+// validateUpload checks an uploaded file's size, format and extracted text
+func (h *EvaluationHandler) validateUpload(u *upload) error {
+	if err := h.validator.CheckSize(u.field, u.file.Size); err != nil {
+		return err
+	}
+
+	f, err := u.file.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	u.data, err = io.ReadAll(io.LimitReader(f, h.validator.MaxFileSize()+1))
+	if err != nil {
+		return err
+	}
+
+	u.doc, err = h.validator.Validate(u.field, u.file.Filename, u.data)
+	return err
+}
+
+// uploadErrorStatus maps an upload validation failure to its HTTP status
+func uploadErrorStatus(code string) int {
+	switch code {
+	case service.UploadEmptyFile:
+		return fiber.StatusBadRequest
+	case service.UploadFileTooLarge:
+		return fiber.StatusRequestEntityTooLarge
+	case service.UploadUnsupportedFormat:
+		return fiber.StatusUnsupportedMediaType
+	default:
+		return fiber.StatusUnprocessableEntity
+	}
+}
+
 func (h *EvaluationHandler) GetResult(c *fiber.Ctx) error {
 	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
@@ -76,9 +181,75 @@ func (h *EvaluationHandler) GetResult(c *fiber.Ctx) error {
 		"status": result.Status,
 	}
 
-	if result.Status == "completed" {
+	if result.Status == domain.StatusCompleted {
 		response["result"] = result.Result
 	}
 
+	// Optional sections, e.g. ?include=analysis
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		switch strings.TrimSpace(include) {
+		case "analysis":
+			response["analysis"] = result.Analysis
+		case "metadata":
+			response["metadata"] = result.Metadata
+		}
+	}
+
+	if result.Status == domain.StatusFailed {
+		response["error_code"] = result.ErrorCode
+		response["error_message"] = result.ErrorMessage
+		response["failed_stage"] = result.FailedStage
+	}
+
+	// The result was cleared when its retention period expired
+	if result.RedactedAt != nil {
+		response["redacted_at"] = result.RedactedAt
+	}
+
 	return c.Status(fiber.StatusOK).JSON(response)
 }
+
+// Cancel stops a queued or processing evaluation
+func (h *EvaluationHandler) Cancel(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id format"})
+	}
+
+	eval, err := h.service.CancelEvaluation(c.Context(), id)
+	switch {
+	case errors.Is(err, service.ErrEvaluationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrEvaluationFinished):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		log.Printf("Error cancelling evaluation %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not cancel evaluation"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"id":     eval.ID.String(),
+		"status": eval.Status,
+	})
+}
+
+// Delete removes an evaluation together with the candidate's files and result
+func (h *EvaluationHandler) Delete(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id format"})
+	}
+
+	err = h.retention.DeleteEvaluation(c.Context(), id)
+	switch {
+	case errors.Is(err, service.ErrEvaluationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrEvaluationInProgress), errors.Is(err, service.ErrFilesInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		log.Printf("Error deleting evaluation %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not delete evaluation"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"aicvevaluator/internal/ai"
+	"aicvevaluator/internal/repository/repotest"
+	"aicvevaluator/internal/service"
+	"aicvevaluator/internal/storage"
+	"aicvevaluator/internal/util"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	testCV     = "Jane Doe\n\nSkills\nGo, PostgreSQL, Docker\n\nExperience\nBackend engineer at Acme, 4 years"
+	testReport = "The project is a Go service with a durable queue, retries and a two-stage LLM pipeline."
+)
+
+// testServer serves the evaluation routes over in-memory repositories and the fake LLM
+type testServer struct {
+	app    *fiber.App
+	docs   *repotest.DocumentRepo
+	worker *service.Worker
+}
+
+func newTestServer(t *testing.T, faults string, queueDepth int) *testServer {
+	t.Helper()
+
+	files, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm, err := ai.NewFakeProviderFromConfig("", faults)
+	if err != nil {
+		t.Fatalf("NewFakeProviderFromConfig(%q) error = %v", faults, err)
+	}
+
+	fileReader := util.NewFileReader()
+	docs := repotest.NewDocumentRepo()
+	documents := service.NewDocumentService(docs, files)
+	evals := repotest.NewEvaluationRepo()
+	pipeline := ai.NewPipeline(files, fileReader, documents, nil, nil, llm,
+		ai.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		ai.RetrievalPolicy{TopK: 2})
+	worker := service.NewWorker(evals, pipeline, service.WorkerConfig{
+		Concurrency:       1,
+		QueueDepth:        queueDepth,
+		PollInterval:      10 * time.Millisecond,
+		LeaseDuration:     time.Minute,
+		HeartbeatInterval: time.Second,
+		MaxAttempts:       3,
+	})
+
+	h := NewEvaluationHandler(
+		service.NewEvaluationService(evals, repotest.NewJobDescriptionRepo(), worker, map[string]string{"backend": "guidelines_backend"}),
+		service.NewUploadValidator(fileReader, service.UploadLimits{MaxFileSize: 1 << 20, MinTextLength: 20}),
+		documents,
+		nil,
+	)
+
+	app := fiber.New()
+	api := app.Group("/api/v1")
+	api.Post("/evaluate", h.Evaluate)
+	api.Get("/result/:id", h.GetResult)
+
+	return &testServer{app: app, docs: docs, worker: worker}
+}
+
+// start runs the worker until the test ends
+func (s *testServer) start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.worker.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// do sends req and decodes the JSON response body
+func (s *testServer) do(t *testing.T, req *http.Request) (int, map[string]any) {
+	t.Helper()
+
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("%s %s: decoding response: %v", req.Method, req.URL.Path, err)
+	}
+	return resp.StatusCode, body
+}
+
+// evaluateRequest builds a multipart /evaluate request with a CV, a report and form fields
+func evaluateRequest(t *testing.T, cv, report string, fields map[string]string) *http.Request {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for name, content := range map[string]string{"cv": cv, "project_report": report} {
+		part, err := w.CreateFormFile(name, name+".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(content))
+	}
+	for name, value := range fields {
+		w.WriteField(name, value)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/evaluate", &buf)
+	req.Header.Set(fiber.HeaderContentType, w.FormDataContentType())
+	return req
+}
+
+func TestEvaluateAndGetResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		faults     string
+		wantStatus string
+		wantCode   string
+	}{
+		{"completed", "", "completed", ""},
+		{"recovers from a transient fault", "stage1=unavailable", "completed", ""},
+		{"failed stage is reported", "stage2=safety", "failed", ai.CodeSafetyBlocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, tt.faults, 0)
+			srv.start(t)
+
+			status, body := srv.do(t, evaluateRequest(t, testCV, testReport, map[string]string{"job_description": "Backend engineer with Go"}))
+			if status != fiber.StatusAccepted || body["status"] != "queued" {
+				t.Fatalf("POST /evaluate = %d %v, want 202 queued", status, body)
+			}
+			id, _ := body["id"].(string)
+
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				status, body = srv.do(t, httptest.NewRequest(http.MethodGet, "/api/v1/result/"+id, nil))
+				if status != fiber.StatusOK {
+					t.Fatalf("GET /result = %d %v", status, body)
+				}
+				if body["status"] == "completed" || body["status"] == "failed" {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("evaluation still %v after 5s", body["status"])
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			if body["status"] != tt.wantStatus {
+				t.Fatalf("status = %v (%v), want %s", body["status"], body["error_message"], tt.wantStatus)
+			}
+			if tt.wantStatus == "completed" {
+				result, _ := body["result"].(map[string]any)
+				if result["cv_match_rate"] == nil || result["project_score"] == nil {
+					t.Errorf("result = %v, want scores", body["result"])
+				}
+			}
+			if code, _ := body["error_code"].(string); code != tt.wantCode {
+				t.Errorf("error_code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestEvaluateRejectedStoresNothing(t *testing.T) {
+	tests := []struct {
+		name       string
+		fields     map[string]string
+		queueDepth int
+		queued     int // evaluations queued before the request
+		wantStatus int
+	}{
+		{"unknown track", map[string]string{"track": "frontend"}, 0, 0, fiber.StatusBadRequest},
+		{"unknown job description", map[string]string{"job_description_id": "00000000-0000-0000-0000-000000000001"}, 0, 0, fiber.StatusNotFound},
+		{"queue full", nil, 1, 1, fiber.StatusServiceUnavailable},
+		{"unreadable upload", nil, 0, 0, fiber.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The worker is not started, so queued evaluations stay queued
+			srv := newTestServer(t, "", tt.queueDepth)
+			for i := 0; i < tt.queued; i++ {
+				if status, body := srv.do(t, evaluateRequest(t, testCV, testReport, nil)); status != fiber.StatusAccepted {
+					t.Fatalf("POST /evaluate = %d %v", status, body)
+				}
+			}
+			before := srv.docs.Len()
+
+			cv := testCV + "\nRejected upload"
+			if tt.wantStatus == fiber.StatusUnprocessableEntity {
+				cv = "Jane Doe"
+			}
+			status, body := srv.do(t, evaluateRequest(t, cv, testReport+"\nRejected upload", tt.fields))
+			if status != tt.wantStatus {
+				t.Errorf("POST /evaluate = %d %v, want %d", status, body, tt.wantStatus)
+			}
+			if after := srv.docs.Len(); after != before {
+				t.Errorf("stored %d documents for a rejected request", after-before)
+			}
+		})
+	}
+}
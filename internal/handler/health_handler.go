@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"aicvevaluator/internal/chromadb"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthHandler struct {
+	chroma *chromadb.Client
+}
+
+// NewHealthHandler creates a health handler; chroma is nil when ChromaDB is not used
+func NewHealthHandler(chroma *chromadb.Client) *HealthHandler {
+	return &HealthHandler{chroma: chroma}
+}
+
+// Health reports the service as degraded while the ChromaDB circuit breaker is not closed.
+// Retrieval then falls back to the embedded store or default guidelines, so it still answers 200.
+func (h *HealthHandler) Health(c *fiber.Ctx) error {
+	if h.chroma == nil {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":   "ok",
+			"chromadb": fiber.Map{"state": "disabled"},
+		})
+	}
+
+	breaker := h.chroma.CircuitStatus()
+	status := "ok"
+	if breaker.State != chromadb.CircuitClosed {
+		status = "degraded"
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":   status,
+		"chromadb": breaker,
+	})
+}
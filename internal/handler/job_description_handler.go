@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"aicvevaluator/internal/service"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type JobDescriptionHandler struct {
+	service service.JobDescriptionService
+}
+
+func NewJobDescriptionHandler(s service.JobDescriptionService) *JobDescriptionHandler {
+	return &JobDescriptionHandler{service: s}
+}
+
+type jobDescriptionRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+func (h *JobDescriptionHandler) Create(c *fiber.Ctx) error {
+	var req jobDescriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	jd, err := h.service.Create(c.Context(), req.Title, req.Description)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(jd)
+}
+
+func (h *JobDescriptionHandler) List(c *fiber.Ctx) error {
+	jds, err := h.service.List(c.Context())
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(jds)
+}
+
+func (h *JobDescriptionHandler) Get(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id format"})
+	}
+
+	jd, err := h.service.Get(c.Context(), id)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(jd)
+}
+
+func (h *JobDescriptionHandler) Update(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id format"})
+	}
+
+	var req jobDescriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	jd, err := h.service.Update(c.Context(), id, req.Title, req.Description)
+	if err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(jd)
+}
+
+func (h *JobDescriptionHandler) Delete(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id format"})
+	}
+
+	if err := h.service.Delete(c.Context(), id); err != nil {
+		return h.handleError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// handleError maps service errors onto HTTP responses
+func (h *JobDescriptionHandler) handleError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrJobDescriptionNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidJobDescription):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	log.Printf("Error handling job description request: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "internal server error"})
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"aicvevaluator/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DocumentRepository defines the contract for uploaded document storage
+type DocumentRepository interface {
+	// Create inserts doc unless a document with the same hash exists, and returns the stored
+	// document either way
+	Create(ctx context.Context, doc *domain.Document) (*domain.Document, error)
+	Reuse(ctx context.Context, hash string) (*domain.Document, error)
+
+	// Extracted text cache, keyed by storage key
+	FindExtracted(ctx context.Context, storageKey string) (*json.RawMessage, error)
+	SetExtracted(ctx context.Context, storageKey string, extracted json.RawMessage) error
+}
+
+// postgresDocumentRepo implements DocumentRepository for PostgreSQL
+type postgresDocumentRepo struct {
+	db *sqlx.DB
+}
+
+// NewDocumentRepository creates a new instance of the repository
+func NewDocumentRepository(db *sqlx.DB) DocumentRepository {
+	return &postgresDocumentRepo{db: db}
+}
+
+const documentColumns = `id, sha256, storage_key, size, mime_type, extracted, created_at, last_used_at`
+
+func (r *postgresDocumentRepo) Create(ctx context.Context, doc *domain.Document) (*domain.Document, error) {
+	var stored domain.Document
+	query := `INSERT INTO documents (id, sha256, storage_key, size, mime_type, extracted, created_at, last_used_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+			  ON CONFLICT (sha256) DO NOTHING
+			  RETURNING ` + documentColumns
+	err := r.db.GetContext(ctx, &stored, query, doc.ID, doc.SHA256, doc.StorageKey, doc.Size, doc.MIMEType, doc.Extracted, doc.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The same content was stored concurrently
+		return r.Reuse(ctx, doc.SHA256)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// Reuse returns the document with the given hash and records that it was uploaded again,
+// which keeps the retention janitor from deleting it. It returns sql.ErrNoRows when no
+// document has the hash.
+func (r *postgresDocumentRepo) Reuse(ctx context.Context, hash string) (*domain.Document, error) {
+	var doc domain.Document
+	query := `UPDATE documents SET last_used_at = NOW()
+			  WHERE sha256 = $1
+			  RETURNING ` + documentColumns
+	err := r.db.GetContext(ctx, &doc, query, hash)
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// FindExtracted returns nil when the document is unknown or its text has not been cached
+func (r *postgresDocumentRepo) FindExtracted(ctx context.Context, storageKey string) (*json.RawMessage, error) {
+	var extracted *json.RawMessage
+	query := `SELECT extracted FROM documents WHERE storage_key = $1`
+	err := r.db.GetContext(ctx, &extracted, query, storageKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return extracted, err
+}
+
+// SetExtracted is a no-op for files that are not recorded as documents
+func (r *postgresDocumentRepo) SetExtracted(ctx context.Context, storageKey string, extracted json.RawMessage) error {
+	query := `UPDATE documents SET extracted = $2 WHERE storage_key = $1`
+	_, err := r.db.ExecContext(ctx, query, storageKey, extracted)
+	return err
+}
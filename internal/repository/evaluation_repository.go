@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"time"
 
 	"aicvevaluator/internal/domain"
 
@@ -9,11 +12,33 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+var (
+	// ErrLeaseLost is returned when a worker no longer owns the lease on an evaluation
+	ErrLeaseLost = errors.New("evaluation lease lost")
+
+	// ErrEvaluationCancelled is returned to a worker whose evaluation was cancelled
+	ErrEvaluationCancelled = errors.New("evaluation cancelled")
+
+	// ErrQueueFull is returned when queueing an evaluation would exceed the queue depth
+	ErrQueueFull = errors.New("evaluation queue is full")
+)
+
 // EvaluationRepository defines the contract for database operations
 type EvaluationRepository interface {
-	Create(ctx context.Context, evaluation *domain.Evaluation) error
+	// Create queues an evaluation unless maxQueued evaluations are already queued; a
+	// maxQueued of 0 means no limit
+	Create(ctx context.Context, evaluation *domain.Evaluation, maxQueued int) error
 	FindByID(ctx context.Context, id uuid.UUID) (*domain.Evaluation, error)
-	Update(ctx context.Context, evaluation *domain.Evaluation) error
+	Finish(ctx context.Context, evaluation *domain.Evaluation, workerID string) error
+	Cancel(ctx context.Context, id uuid.UUID) (*domain.Evaluation, error)
+
+	// Queue operations
+	ClaimNext(ctx context.Context, workerID string, lease time.Duration) (*domain.Evaluation, error)
+	Heartbeat(ctx context.Context, id uuid.UUID, workerID string, lease time.Duration) error
+	Release(ctx context.Context, id uuid.UUID, workerID string) error
+	ReclaimStale(ctx context.Context, maxAttempts int) (int64, error)
+	CountByStatus(ctx context.Context, status domain.EvaluationStatus) (int, error)
+	AppendLLMAttempt(ctx context.Context, id uuid.UUID, attempt json.RawMessage) error
 }
 
 // postgresEvaluationRepo implements EvaluationRepository for PostgreSQL
@@ -26,25 +51,183 @@ func NewEvaluationRepository(db *sqlx.DB) EvaluationRepository {
 	return &postgresEvaluationRepo{db: db}
 }
 
-func (r *postgresEvaluationRepo) Create(ctx context.Context, eval *domain.Evaluation) error {
-	query := `INSERT INTO evaluations (id, status, cv_path, report_path, created_at, updated_at)
-			  VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := r.db.ExecContext(ctx, query, eval.ID, eval.Status, eval.CVPath, eval.ReportPath, eval.CreatedAt, eval.UpdatedAt)
-	return err
+const evaluationColumns = `id, status, cv_key, report_key, cv_document_id, report_document_id,
+			  job_description_id, job_description, track, collection,
+			  result, analysis, metadata, created_at, updated_at,
+			  attempts, lease_owner, lease_expires_at, llm_attempts,
+			  error_code, error_message, failed_stage, files_deleted_at, redacted_at`
+
+// queueLockKey is the advisory lock serialising inserts into a bounded queue, so concurrent
+// requests cannot all see room for one more job
+const queueLockKey = 7207011
+
+func (r *postgresEvaluationRepo) Create(ctx context.Context, eval *domain.Evaluation, maxQueued int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if maxQueued > 0 {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, queueLockKey); err != nil {
+			return err
+		}
+		var queued int
+		if err := tx.GetContext(ctx, &queued, `SELECT COUNT(*) FROM evaluations WHERE status = $1`, domain.StatusQueued); err != nil {
+			return err
+		}
+		if queued >= maxQueued {
+			return ErrQueueFull
+		}
+	}
+
+	query := `INSERT INTO evaluations (id, status, cv_key, report_key, cv_document_id, report_document_id,
+			  job_description_id, job_description, track, collection, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	if _, err := tx.ExecContext(ctx, query, eval.ID, eval.Status, eval.CVKey, eval.ReportKey, eval.CVDocumentID, eval.ReportDocumentID,
+		eval.JobDescriptionID, eval.JobDescription, eval.Track, eval.Collection, eval.CreatedAt, eval.UpdatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *postgresEvaluationRepo) FindByID(ctx context.Context, id uuid.UUID) (*domain.Evaluation, error) {
 	var eval domain.Evaluation
-	query := `SELECT id, status, cv_path, report_path, result, created_at, updated_at
+	query := `SELECT ` + evaluationColumns + `
 			  FROM evaluations WHERE id = $1`
 	err := r.db.GetContext(ctx, &eval, query, id)
 	return &eval, err
 }
 
-func (r *postgresEvaluationRepo) Update(ctx context.Context, eval *domain.Evaluation) error {
-	query := `UPDATE evaluations 
-			  SET status = $2, result = $3, updated_at = NOW()
+// Finish stores the final status of an evaluation processed by workerID and drops its lease.
+// It returns ErrEvaluationCancelled if the evaluation was cancelled meanwhile, and ErrLeaseLost
+// if the lease was reclaimed, so a run that lost its job cannot overwrite another run's result.
+func (r *postgresEvaluationRepo) Finish(ctx context.Context, eval *domain.Evaluation, workerID string) error {
+	query := `UPDATE evaluations
+			  SET status = $2, result = $3, analysis = $4, metadata = $5, lease_owner = NULL, lease_expires_at = NULL,
+			      error_code = $6, error_message = $7, failed_stage = $8, updated_at = NOW()
+			  WHERE id = $1 AND lease_owner = $9 AND status = $10`
+	res, err := r.db.ExecContext(ctx, query, eval.ID, eval.Status, eval.Result, eval.Analysis, eval.Metadata,
+		eval.ErrorCode, eval.ErrorMessage, eval.FailedStage, workerID, domain.StatusProcessing)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n > 0 {
+		return err
+	}
+	return r.leaseError(ctx, eval.ID)
+}
+
+// Cancel moves a queued or processing evaluation to cancelled and drops its lease, so it is
+// never claimed again. It returns sql.ErrNoRows if no such evaluation is queued or processing.
+func (r *postgresEvaluationRepo) Cancel(ctx context.Context, id uuid.UUID) (*domain.Evaluation, error) {
+	var eval domain.Evaluation
+	query := `UPDATE evaluations
+			  SET status = $2, lease_owner = NULL, lease_expires_at = NULL, updated_at = NOW()
+			  WHERE id = $1 AND status IN ($3, $4)
+			  RETURNING ` + evaluationColumns
+	err := r.db.GetContext(ctx, &eval, query, id, domain.StatusCancelled, domain.StatusQueued, domain.StatusProcessing)
+	if err != nil {
+		return nil, err
+	}
+	return &eval, nil
+}
+
+// ClaimNext leases the oldest queued evaluation to the given worker.
+// SKIP LOCKED lets several workers poll concurrently without handing out the same job.
+// It returns sql.ErrNoRows when the queue is empty.
+func (r *postgresEvaluationRepo) ClaimNext(ctx context.Context, workerID string, lease time.Duration) (*domain.Evaluation, error) {
+	var eval domain.Evaluation
+	query := `UPDATE evaluations
+			  SET status = $1, lease_owner = $2, lease_expires_at = NOW() + make_interval(secs => $3),
+			      attempts = attempts + 1, updated_at = NOW()
+			  WHERE id = (
+			      SELECT id FROM evaluations
+			      WHERE status = $4
+			      ORDER BY created_at
+			      LIMIT 1
+			      FOR UPDATE SKIP LOCKED
+			  )
+			  RETURNING ` + evaluationColumns
+	err := r.db.GetContext(ctx, &eval, query, domain.StatusProcessing, workerID, lease.Seconds(), domain.StatusQueued)
+	if err != nil {
+		return nil, err
+	}
+	return &eval, nil
+}
+
+// Heartbeat extends the lease held by workerID. It returns ErrEvaluationCancelled when the
+// evaluation was cancelled, and ErrLeaseLost when it has been reclaimed or is otherwise no
+// longer processing.
+func (r *postgresEvaluationRepo) Heartbeat(ctx context.Context, id uuid.UUID, workerID string, lease time.Duration) error {
+	query := `UPDATE evaluations
+			  SET lease_expires_at = NOW() + make_interval(secs => $3), updated_at = NOW()
+			  WHERE id = $1 AND lease_owner = $2 AND status = $4`
+	res, err := r.db.ExecContext(ctx, query, id, workerID, lease.Seconds(), domain.StatusProcessing)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	return r.leaseError(ctx, id)
+}
+
+// leaseError tells why a worker's lease on an evaluation no longer holds
+func (r *postgresEvaluationRepo) leaseError(ctx context.Context, id uuid.UUID) error {
+	var status domain.EvaluationStatus
+	err := r.db.GetContext(ctx, &status, `SELECT status FROM evaluations WHERE id = $1`, id)
+	if err == nil && status == domain.StatusCancelled {
+		return ErrEvaluationCancelled
+	}
+	return ErrLeaseLost
+}
+
+// Release puts an evaluation owned by workerID back on the queue without
+// counting the interrupted run as an attempt (used on graceful shutdown).
+func (r *postgresEvaluationRepo) Release(ctx context.Context, id uuid.UUID, workerID string) error {
+	query := `UPDATE evaluations
+			  SET status = $3, lease_owner = NULL, lease_expires_at = NULL,
+			      attempts = GREATEST(attempts - 1, 0), updated_at = NOW()
+			  WHERE id = $1 AND lease_owner = $2 AND status = $4`
+	_, err := r.db.ExecContext(ctx, query, id, workerID, domain.StatusQueued, domain.StatusProcessing)
+	return err
+}
+
+// ReclaimStale re-queues processing evaluations whose lease has expired, e.g. because
+// the worker crashed. Jobs that already used maxAttempts are marked failed instead.
+func (r *postgresEvaluationRepo) ReclaimStale(ctx context.Context, maxAttempts int) (int64, error) {
+	query := `UPDATE evaluations
+			  SET status = CASE WHEN attempts >= $1 THEN $2 ELSE $3 END,
+			      error_code = CASE WHEN attempts >= $1 THEN $5 ELSE error_code END,
+			      error_message = CASE WHEN attempts >= $1 THEN $6 ELSE error_message END,
+			      lease_owner = NULL, lease_expires_at = NULL, updated_at = NOW()
+			  WHERE status = $4 AND (lease_expires_at IS NULL OR lease_expires_at < NOW())`
+	res, err := r.db.ExecContext(ctx, query, maxAttempts, domain.StatusFailed, domain.StatusQueued, domain.StatusProcessing,
+		domain.ErrorCodeMaxAttempts, "worker stopped responding too many times while processing this evaluation")
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *postgresEvaluationRepo) CountByStatus(ctx context.Context, status domain.EvaluationStatus) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM evaluations WHERE status = $1`
+	err := r.db.GetContext(ctx, &count, query, status)
+	return count, err
+}
+
+// AppendLLMAttempt adds a single attempt record to the evaluation's llm_attempts array
+func (r *postgresEvaluationRepo) AppendLLMAttempt(ctx context.Context, id uuid.UUID, attempt json.RawMessage) error {
+	query := `UPDATE evaluations
+			  SET llm_attempts = llm_attempts || jsonb_build_array($2::jsonb), updated_at = NOW()
 			  WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, eval.ID, eval.Status, eval.Result)
+	_, err := r.db.ExecContext(ctx, query, id, string(attempt))
 	return err
 }
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"aicvevaluator/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// JobDescriptionRepository defines the contract for job description storage
+type JobDescriptionRepository interface {
+	Create(ctx context.Context, jd *domain.JobDescription) error
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.JobDescription, error)
+	List(ctx context.Context) ([]domain.JobDescription, error)
+	Update(ctx context.Context, jd *domain.JobDescription) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// postgresJobDescriptionRepo implements JobDescriptionRepository for PostgreSQL
+type postgresJobDescriptionRepo struct {
+	db *sqlx.DB
+}
+
+// NewJobDescriptionRepository creates a new instance of the repository
+func NewJobDescriptionRepository(db *sqlx.DB) JobDescriptionRepository {
+	return &postgresJobDescriptionRepo{db: db}
+}
+
+func (r *postgresJobDescriptionRepo) Create(ctx context.Context, jd *domain.JobDescription) error {
+	query := `INSERT INTO job_descriptions (id, title, description, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.ExecContext(ctx, query, jd.ID, jd.Title, jd.Description, jd.CreatedAt, jd.UpdatedAt)
+	return err
+}
+
+func (r *postgresJobDescriptionRepo) FindByID(ctx context.Context, id uuid.UUID) (*domain.JobDescription, error) {
+	var jd domain.JobDescription
+	query := `SELECT id, title, description, created_at, updated_at
+			  FROM job_descriptions WHERE id = $1`
+	err := r.db.GetContext(ctx, &jd, query, id)
+	return &jd, err
+}
+
+func (r *postgresJobDescriptionRepo) List(ctx context.Context) ([]domain.JobDescription, error) {
+	jds := make([]domain.JobDescription, 0)
+	query := `SELECT id, title, description, created_at, updated_at
+			  FROM job_descriptions ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &jds, query)
+	return jds, err
+}
+
+// Update returns sql.ErrNoRows when the job description does not exist
+func (r *postgresJobDescriptionRepo) Update(ctx context.Context, jd *domain.JobDescription) error {
+	query := `UPDATE job_descriptions
+			  SET title = $2, description = $3, updated_at = NOW()
+			  WHERE id = $1`
+	res, err := r.db.ExecContext(ctx, query, jd.ID, jd.Title, jd.Description)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(res)
+}
+
+// Delete returns sql.ErrNoRows when the job description does not exist
+func (r *postgresJobDescriptionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM job_descriptions WHERE id = $1`
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(res)
+}
+
+// expectOneRow turns a statement that affected no rows into sql.ErrNoRows
+func expectOneRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+// Package repotest provides in-memory repositories for tests
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"aicvevaluator/internal/domain"
+	"aicvevaluator/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// EvaluationRepo is an in-memory repository.EvaluationRepository with the same lease rules
+// as the PostgreSQL one
+type EvaluationRepo struct {
+	mu    sync.Mutex
+	evals map[uuid.UUID]*domain.Evaluation
+}
+
+// NewEvaluationRepo creates an empty evaluation repository
+func NewEvaluationRepo() *EvaluationRepo {
+	return &EvaluationRepo{evals: make(map[uuid.UUID]*domain.Evaluation)}
+}
+
+// clone copies eval so callers never share the stored row
+func clone(eval *domain.Evaluation) *domain.Evaluation {
+	c := *eval
+	return &c
+}
+
+func (r *EvaluationRepo) Create(ctx context.Context, eval *domain.Evaluation, maxQueued int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if maxQueued > 0 {
+		queued := 0
+		for _, e := range r.evals {
+			if e.Status == domain.StatusQueued {
+				queued++
+			}
+		}
+		if queued >= maxQueued {
+			return repository.ErrQueueFull
+		}
+	}
+	attempts := json.RawMessage("[]")
+	stored := clone(eval)
+	stored.LLMAttempts = &attempts
+	r.evals[eval.ID] = stored
+	return nil
+}
+
+func (r *EvaluationRepo) FindByID(ctx context.Context, id uuid.UUID) (*domain.Evaluation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	eval, ok := r.evals[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return clone(eval), nil
+}
+
+func (r *EvaluationRepo) Finish(ctx context.Context, eval *domain.Evaluation, workerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.evals[eval.ID]
+	if !ok {
+		return repository.ErrLeaseLost
+	}
+	if err := leaseError(stored, workerID); err != nil {
+		return err
+	}
+	stored.Status, stored.Result, stored.Analysis, stored.Metadata = eval.Status, eval.Result, eval.Analysis, eval.Metadata
+	stored.ErrorCode, stored.ErrorMessage, stored.FailedStage = eval.ErrorCode, eval.ErrorMessage, eval.FailedStage
+	stored.LeaseOwner, stored.LeaseExpiresAt = nil, nil
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+// leaseError tells why workerID no longer holds the lease on eval, if it does not
+func leaseError(eval *domain.Evaluation, workerID string) error {
+	switch {
+	case eval.Status == domain.StatusCancelled:
+		return repository.ErrEvaluationCancelled
+	case eval.Status != domain.StatusProcessing || eval.LeaseOwner == nil || *eval.LeaseOwner != workerID:
+		return repository.ErrLeaseLost
+	}
+	return nil
+}
+
+func (r *EvaluationRepo) Cancel(ctx context.Context, id uuid.UUID) (*domain.Evaluation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	eval, ok := r.evals[id]
+	if !ok || (eval.Status != domain.StatusQueued && eval.Status != domain.StatusProcessing) {
+		return nil, sql.ErrNoRows
+	}
+	eval.Status = domain.StatusCancelled
+	eval.LeaseOwner, eval.LeaseExpiresAt = nil, nil
+	return clone(eval), nil
+}
+
+func (r *EvaluationRepo) ClaimNext(ctx context.Context, workerID string, lease time.Duration) (*domain.Evaluation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var queued []*domain.Evaluation
+	for _, eval := range r.evals {
+		if eval.Status == domain.StatusQueued {
+			queued = append(queued, eval)
+		}
+	}
+	if len(queued) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	sort.Slice(queued, func(i, j int) bool { return queued[i].CreatedAt.Before(queued[j].CreatedAt) })
+
+	eval := queued[0]
+	expires := time.Now().Add(lease)
+	eval.Status = domain.StatusProcessing
+	eval.LeaseOwner, eval.LeaseExpiresAt = &workerID, &expires
+	eval.Attempts++
+	return clone(eval), nil
+}
+
+func (r *EvaluationRepo) Heartbeat(ctx context.Context, id uuid.UUID, workerID string, lease time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	eval, ok := r.evals[id]
+	if !ok {
+		return repository.ErrLeaseLost
+	}
+	if err := leaseError(eval, workerID); err != nil {
+		return err
+	}
+	expires := time.Now().Add(lease)
+	eval.LeaseExpiresAt = &expires
+	return nil
+}
+
+func (r *EvaluationRepo) Release(ctx context.Context, id uuid.UUID, workerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if eval, ok := r.evals[id]; ok && leaseError(eval, workerID) == nil {
+		eval.Status = domain.StatusQueued
+		eval.LeaseOwner, eval.LeaseExpiresAt = nil, nil
+		eval.Attempts = max(eval.Attempts-1, 0)
+	}
+	return nil
+}
+
+func (r *EvaluationRepo) ReclaimStale(ctx context.Context, maxAttempts int) (int64, error) {
+	return 0, nil
+}
+
+func (r *EvaluationRepo) CountByStatus(ctx context.Context, status domain.EvaluationStatus) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for _, eval := range r.evals {
+		if eval.Status == status {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *EvaluationRepo) AppendLLMAttempt(ctx context.Context, id uuid.UUID, attempt json.RawMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	eval, ok := r.evals[id]
+	if !ok {
+		return nil
+	}
+	var attempts []json.RawMessage
+	json.Unmarshal(*eval.LLMAttempts, &attempts)
+	data, _ := json.Marshal(append(attempts, attempt))
+	raw := json.RawMessage(data)
+	eval.LLMAttempts = &raw
+	return nil
+}
+
+// JobDescriptionRepo is an in-memory repository.JobDescriptionRepository
+type JobDescriptionRepo struct {
+	jds map[uuid.UUID]*domain.JobDescription
+}
+
+// NewJobDescriptionRepo creates an empty job description repository
+func NewJobDescriptionRepo() *JobDescriptionRepo {
+	return &JobDescriptionRepo{jds: make(map[uuid.UUID]*domain.JobDescription)}
+}
+
+func (r *JobDescriptionRepo) Create(ctx context.Context, jd *domain.JobDescription) error {
+	r.jds[jd.ID] = jd
+	return nil
+}
+
+func (r *JobDescriptionRepo) FindByID(ctx context.Context, id uuid.UUID) (*domain.JobDescription, error) {
+	jd, ok := r.jds[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return jd, nil
+}
+
+func (r *JobDescriptionRepo) List(ctx context.Context) ([]domain.JobDescription, error) {
+	var jds []domain.JobDescription
+	for _, jd := range r.jds {
+		jds = append(jds, *jd)
+	}
+	return jds, nil
+}
+
+func (r *JobDescriptionRepo) Update(ctx context.Context, jd *domain.JobDescription) error {
+	if _, ok := r.jds[jd.ID]; !ok {
+		return sql.ErrNoRows
+	}
+	r.jds[jd.ID] = jd
+	return nil
+}
+
+func (r *JobDescriptionRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(r.jds, id)
+	return nil
+}
+
+// DocumentRepo is an in-memory repository.DocumentRepository
+type DocumentRepo struct {
+	mu   sync.Mutex
+	docs map[string]*domain.Document // by hash
+}
+
+// NewDocumentRepo creates an empty document repository
+func NewDocumentRepo() *DocumentRepo {
+	return &DocumentRepo{docs: make(map[string]*domain.Document)}
+}
+
+// Len returns the number of stored documents
+func (r *DocumentRepo) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.docs)
+}
+
+func (r *DocumentRepo) Create(ctx context.Context, doc *domain.Document) (*domain.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.docs[doc.SHA256]
+	if !ok {
+		c := *doc
+		c.LastUsedAt = c.CreatedAt
+		stored = &c
+		r.docs[doc.SHA256] = stored
+	}
+	c := *stored
+	return &c, nil
+}
+
+func (r *DocumentRepo) Reuse(ctx context.Context, hash string) (*domain.Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.docs[hash]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	stored.LastUsedAt = time.Now()
+	c := *stored
+	return &c, nil
+}
+
+func (r *DocumentRepo) FindExtracted(ctx context.Context, storageKey string) (*json.RawMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, doc := range r.docs {
+		if doc.StorageKey == storageKey {
+			return doc.Extracted, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *DocumentRepo) SetExtracted(ctx context.Context, storageKey string, extracted json.RawMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, doc := range r.docs {
+		if doc.StorageKey == storageKey {
+			doc.Extracted = &extracted
+		}
+	}
+	return nil
+}
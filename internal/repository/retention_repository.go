@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"aicvevaluator/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrEvaluationInProgress is returned when deleting an evaluation a worker is processing
+	ErrEvaluationInProgress = errors.New("evaluation is being processed")
+
+	// ErrDocumentInUse is returned when detaching documents a queued or processing evaluation uses
+	ErrDocumentInUse = errors.New("document is used by an active evaluation")
+)
+
+// RetentionRepository deletes candidate data and records every deletion in the audit log.
+// Each change and its audit record are written in a single statement.
+type RetentionRepository interface {
+	// FindExpiredFiles returns finished evaluations created before cutoff whose files are still kept
+	FindExpiredFiles(ctx context.Context, cutoff time.Time, limit int) ([]domain.Evaluation, error)
+	// DetachFiles clears the file references of the given evaluations
+	DetachFiles(ctx context.Context, ids []uuid.UUID, reason string) (int64, error)
+
+	// FindUnusedDocuments returns documents no evaluation refers to that were last used before cutoff
+	FindUnusedDocuments(ctx context.Context, cutoff time.Time, limit int) ([]domain.Document, error)
+	// DeleteDocument deletes a document unless an evaluation started using it again, and
+	// reports whether it was deleted
+	DeleteDocument(ctx context.Context, id uuid.UUID, reason string) (bool, error)
+
+	// RedactResults clears the results and candidate data of up to limit finished evaluations
+	// created before cutoff
+	RedactResults(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+
+	// DeleteEvaluation deletes an evaluation and returns it. Its documents are detached from
+	// every other evaluation, so they can be deleted too. It changes nothing and returns
+	// sql.ErrNoRows if the evaluation does not exist, ErrEvaluationInProgress if a worker is
+	// processing it, and ErrDocumentInUse if another evaluation using its documents is queued
+	// or processing.
+	DeleteEvaluation(ctx context.Context, id uuid.UUID, reason string) (*domain.Evaluation, error)
+}
+
+// postgresRetentionRepo implements RetentionRepository for PostgreSQL
+type postgresRetentionRepo struct {
+	db *sqlx.DB
+}
+
+// NewRetentionRepository creates a new instance of the repository
+func NewRetentionRepository(db *sqlx.DB) RetentionRepository {
+	return &postgresRetentionRepo{db: db}
+}
+
+// finishedStatuses are the statuses of evaluations no worker will touch again
+var finishedStatuses = pq.Array([]string{string(domain.StatusCompleted), string(domain.StatusFailed), string(domain.StatusCancelled)})
+
+// documentInUse matches documents referenced by an evaluation, aliased d
+const documentInUse = `EXISTS (SELECT 1 FROM evaluations e WHERE e.cv_document_id = d.id OR e.report_document_id = d.id)`
+
+func (r *postgresRetentionRepo) FindExpiredFiles(ctx context.Context, cutoff time.Time, limit int) ([]domain.Evaluation, error) {
+	evals := make([]domain.Evaluation, 0)
+	query := `SELECT ` + evaluationColumns + `
+			  FROM evaluations
+			  WHERE status = ANY($1) AND files_deleted_at IS NULL AND created_at < $2
+			  ORDER BY created_at
+			  LIMIT $3`
+	err := r.db.SelectContext(ctx, &evals, query, finishedStatuses, cutoff, limit)
+	return evals, err
+}
+
+func (r *postgresRetentionRepo) DetachFiles(ctx context.Context, ids []uuid.UUID, reason string) (int64, error) {
+	query := `WITH detached AS (
+				  UPDATE evaluations
+				  SET cv_key = '', report_key = '', cv_document_id = NULL, report_document_id = NULL,
+				      files_deleted_at = NOW(), updated_at = NOW()
+				  WHERE id = ANY($1::uuid[]) AND files_deleted_at IS NULL
+				  RETURNING id
+			  )
+			  INSERT INTO deletion_audit_log (evaluation_id, action, reason)
+			  SELECT id, $2, $3 FROM detached`
+	res, err := r.db.ExecContext(ctx, query, uuidArray(ids), domain.DeletionFilesDeleted, reason)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// detachDocuments clears the references to the given documents from every evaluation but
+// except. It returns ErrDocumentInUse if one of those evaluations is queued or processing.
+func detachDocuments(ctx context.Context, tx *sqlx.Tx, docIDs []uuid.UUID, except uuid.UUID, reason string) error {
+	// Lock the evaluations sharing the documents, so none is claimed while they are detached
+	var statuses []domain.EvaluationStatus
+	query := `SELECT status FROM evaluations
+			  WHERE id <> $2 AND (cv_document_id = ANY($1::uuid[]) OR report_document_id = ANY($1::uuid[]))
+			  FOR UPDATE`
+	if err := tx.SelectContext(ctx, &statuses, query, uuidArray(docIDs), except); err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if status == domain.StatusQueued || status == domain.StatusProcessing {
+			return ErrDocumentInUse
+		}
+	}
+
+	// Only the matching file is detached; files_deleted_at is set once neither file is left
+	query = `WITH detached AS (
+				 UPDATE evaluations
+				 SET cv_key = CASE WHEN cv_document_id = ANY($1::uuid[]) THEN '' ELSE cv_key END,
+				     cv_document_id = CASE WHEN cv_document_id = ANY($1::uuid[]) THEN NULL ELSE cv_document_id END,
+				     report_key = CASE WHEN report_document_id = ANY($1::uuid[]) THEN '' ELSE report_key END,
+				     report_document_id = CASE WHEN report_document_id = ANY($1::uuid[]) THEN NULL ELSE report_document_id END,
+				     files_deleted_at = CASE
+				         WHEN (cv_document_id = ANY($1::uuid[]) OR cv_key = '')
+				          AND (report_document_id = ANY($1::uuid[]) OR report_key = '')
+				         THEN NOW() ELSE files_deleted_at END,
+				     updated_at = NOW()
+				 WHERE id <> $2 AND (cv_document_id = ANY($1::uuid[]) OR report_document_id = ANY($1::uuid[]))
+				 RETURNING id
+			 )
+			 INSERT INTO deletion_audit_log (evaluation_id, action, reason)
+			 SELECT id, $3, $4 FROM detached`
+	_, err := tx.ExecContext(ctx, query, uuidArray(docIDs), except, domain.DeletionFilesDeleted, reason)
+	return err
+}
+
+func (r *postgresRetentionRepo) FindUnusedDocuments(ctx context.Context, cutoff time.Time, limit int) ([]domain.Document, error) {
+	docs := make([]domain.Document, 0)
+	query := `SELECT ` + documentColumns + `
+			  FROM documents d
+			  WHERE d.last_used_at < $1 AND NOT ` + documentInUse + `
+			  ORDER BY d.last_used_at
+			  LIMIT $2`
+	err := r.db.SelectContext(ctx, &docs, query, cutoff, limit)
+	return docs, err
+}
+
+func (r *postgresRetentionRepo) DeleteDocument(ctx context.Context, id uuid.UUID, reason string) (bool, error) {
+	query := `WITH deleted AS (
+				  DELETE FROM documents d
+				  WHERE d.id = $1 AND NOT ` + documentInUse + `
+				  RETURNING id
+			  )
+			  INSERT INTO deletion_audit_log (document_id, action, reason)
+			  SELECT id, $2, $3 FROM deleted`
+	res, err := r.db.ExecContext(ctx, query, id, domain.DeletionDocumentDeleted, reason)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *postgresRetentionRepo) RedactResults(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	query := `WITH redacted AS (
+				  UPDATE evaluations
+				  SET result = NULL, analysis = NULL, job_description = NULL, error_message = NULL,
+				      llm_attempts = '[]'::jsonb, redacted_at = NOW(), updated_at = NOW()
+				  WHERE id IN (
+					  SELECT id FROM evaluations
+					  WHERE status = ANY($1) AND redacted_at IS NULL AND created_at < $2
+					  ORDER BY created_at
+					  LIMIT $3
+				  )
+				  RETURNING id
+			  )
+			  INSERT INTO deletion_audit_log (evaluation_id, action, reason)
+			  SELECT id, $4, $5 FROM redacted`
+	res, err := r.db.ExecContext(ctx, query, finishedStatuses, cutoff, limit, domain.DeletionResultRedacted, domain.DeletionReasonRetention)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *postgresRetentionRepo) DeleteEvaluation(ctx context.Context, id uuid.UUID, reason string) (*domain.Evaluation, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the evaluation, so no worker claims it before it is deleted
+	var eval domain.Evaluation
+	query := `SELECT ` + evaluationColumns + `
+			  FROM evaluations WHERE id = $1
+			  FOR UPDATE`
+	if err := tx.GetContext(ctx, &eval, query, id); err != nil {
+		return nil, err
+	}
+	if eval.Status == domain.StatusProcessing {
+		return nil, ErrEvaluationInProgress
+	}
+
+	var docIDs []uuid.UUID
+	for _, docID := range []*uuid.UUID{eval.CVDocumentID, eval.ReportDocumentID} {
+		if docID != nil {
+			docIDs = append(docIDs, *docID)
+		}
+	}
+	if len(docIDs) > 0 {
+		if err := detachDocuments(ctx, tx, docIDs, id, reason); err != nil {
+			return nil, err
+		}
+	}
+
+	query = `WITH deleted AS (
+				 DELETE FROM evaluations WHERE id = $1
+				 RETURNING id
+			 )
+			 INSERT INTO deletion_audit_log (evaluation_id, action, reason)
+			 SELECT id, $2, $3 FROM deleted`
+	if _, err := tx.ExecContext(ctx, query, id, domain.DeletionEvaluationDeleted, reason); err != nil {
+		return nil, err
+	}
+	return &eval, tx.Commit()
+}
+
+// uuidArray converts ids to a Postgres array parameter
+func uuidArray(ids []uuid.UUID) interface{} {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return pq.Array(values)
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"aicvevaluator/internal/ai"
+	"aicvevaluator/internal/domain"
+	"aicvevaluator/internal/repository"
+	"aicvevaluator/internal/storage"
+	"aicvevaluator/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// DocumentService stores uploaded files by content, so a file uploaded twice is kept once.
+// It also caches the text extracted from each document for the AI pipeline.
+type DocumentService interface {
+	// Store saves data unless a document with the same content exists, and returns the
+	// document either way. extracted is the text extracted during validation, if any.
+	Store(ctx context.Context, data []byte, extracted *util.Document) (*domain.Document, error)
+
+	ai.DocumentCache
+}
+
+type documentService struct {
+	repo  repository.DocumentRepository
+	files storage.Storage
+}
+
+// NewDocumentService creates a new instance of the service
+func NewDocumentService(repo repository.DocumentRepository, files storage.Storage) DocumentService {
+	return &documentService{repo: repo, files: files}
+}
+
+// DocumentKey returns the storage key of content with the given SHA-256 hex digest. Keys are
+// fanned out by the first two hex digits to keep directories small on local storage.
+func DocumentKey(hash string) string {
+	return fmt.Sprintf("sha256/%s/%s", hash[:2], hash)
+}
+
+func (s *documentService) Store(ctx context.Context, data []byte, extracted *util.Document) (*domain.Document, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	existing, err := s.repo.Reuse(ctx, hash)
+	switch {
+	case err == nil:
+		// Put the file back if it went missing, e.g. after a storage migration
+		if _, err := s.files.Stat(ctx, existing.StorageKey); errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Restoring missing file of document %s", existing.ID)
+			if err := s.files.Put(ctx, existing.StorageKey, bytes.NewReader(data), int64(len(data)), existing.MIMEType); err != nil {
+				return nil, fmt.Errorf("failed to save file: %w", err)
+			}
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to check stored file: %w", err)
+		}
+		if existing.Extracted == nil && extracted != nil {
+			if err := s.CacheDocument(ctx, existing.StorageKey, extracted); err != nil {
+				log.Printf("Warning: failed to cache text of %s: %v", existing.StorageKey, err)
+			}
+		}
+		return existing, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	doc := &domain.Document{
+		ID:         uuid.New(),
+		SHA256:     hash,
+		StorageKey: DocumentKey(hash),
+		Size:       int64(len(data)),
+		MIMEType:   "application/octet-stream",
+		CreatedAt:  time.Now(),
+	}
+	if extracted != nil {
+		doc.MIMEType = extracted.Format.MIMEType()
+		raw, err := json.Marshal(extracted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode extracted text: %w", err)
+		}
+		doc.Extracted = (*json.RawMessage)(&raw)
+	}
+
+	// The file is written before the row, so a recorded document always has its content;
+	// a concurrent upload of the same content just writes identical bytes
+	if err := s.files.Put(ctx, doc.StorageKey, bytes.NewReader(data), doc.Size, doc.MIMEType); err != nil {
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	return s.repo.Create(ctx, doc)
+}
+
+func (s *documentService) CachedDocument(ctx context.Context, key string) (*util.Document, error) {
+	raw, err := s.repo.FindExtracted(ctx, key)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+
+	var doc util.Document
+	if err := json.Unmarshal(*raw, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode cached text: %w", err)
+	}
+	return &doc, nil
+}
+
+func (s *documentService) CacheDocument(ctx context.Context, key string, doc *util.Document) error {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetExtracted(ctx, key, raw)
+}
@@ -2,53 +2,139 @@ package service
 
 import (
 	"context"
-	"encoding/json"
-	"log"
+	"database/sql"
+	"errors"
+	"strings"
 	"time"
 
-	"aicvevaluator/internal/ai"
 	"aicvevaluator/internal/domain"
 	"aicvevaluator/internal/repository"
 
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrQueueFull is returned when the evaluation queue has reached its configured depth
+	ErrQueueFull = errors.New("evaluation queue is full")
+
+	// ErrConflictingJobDescription is returned when both a job description ID and inline text are given
+	ErrConflictingJobDescription = errors.New("provide either job_description_id or job_description, not both")
+
+	// ErrUnknownTrack is returned when the requested hiring track has no knowledge base collection
+	ErrUnknownTrack = errors.New("unknown track")
+
+	// ErrEvaluationNotFound is returned when cancelling or deleting an evaluation that does not exist
+	ErrEvaluationNotFound = errors.New("evaluation not found")
+
+	// ErrEvaluationFinished is returned when cancelling an evaluation that has already finished
+	ErrEvaluationFinished = errors.New("evaluation has already finished")
+)
+
+// CreateEvaluationInput holds everything submitted for a new evaluation
+type CreateEvaluationInput struct {
+	// Uploaded files, see DocumentService.Store
+	CVDocument     *domain.Document
+	ReportDocument *domain.Document
+
+	// Optional job requirements: either a stored job description or inline text, not both
+	JobDescriptionID *uuid.UUID
+	JobDescription   string
+
+	// Optional hiring track, e.g. "backend", selecting the guidelines used for retrieval
+	Track string
+}
+
 // EvaluationService defines the business logic operations
 type EvaluationService interface {
-	CreateEvaluation(ctx context.Context, cvPath, reportPath string) (*domain.Evaluation, error)
+	// CheckEvaluation runs the checks of CreateEvaluation that do not need the uploaded files,
+	// so a request that would be rejected stores nothing. The queue may still fill up before
+	// CreateEvaluation, which checks again.
+	CheckEvaluation(ctx context.Context, input CreateEvaluationInput) error
+	CreateEvaluation(ctx context.Context, input CreateEvaluationInput) (*domain.Evaluation, error)
 	GetEvaluationResult(ctx context.Context, id uuid.UUID) (*domain.Evaluation, error)
+	CancelEvaluation(ctx context.Context, id uuid.UUID) (*domain.Evaluation, error)
 }
 
 type evaluationService struct {
-	repo       repository.EvaluationRepository
-	aiPipeline *ai.Pipeline
+	repo             repository.EvaluationRepository
+	jdRepo           repository.JobDescriptionRepository
+	worker           *Worker
+	trackCollections map[string]string
 }
 
-// NewEvaluationService creates a new instance of the service
-func NewEvaluationService(repo repository.EvaluationRepository, aiPipeline *ai.Pipeline) EvaluationService {
+// NewEvaluationService creates a new instance of the service. trackCollections maps each
+// accepted hiring track to the knowledge base collection holding its guidelines.
+func NewEvaluationService(repo repository.EvaluationRepository, jdRepo repository.JobDescriptionRepository, worker *Worker, trackCollections map[string]string) EvaluationService {
 	return &evaluationService{
-		repo:       repo,
-		aiPipeline: aiPipeline,
+		repo:             repo,
+		jdRepo:           jdRepo,
+		worker:           worker,
+		trackCollections: trackCollections,
+	}
+}
+
+func (s *evaluationService) CheckEvaluation(ctx context.Context, input CreateEvaluationInput) error {
+	if _, err := s.resolveJobDescription(ctx, input); err != nil {
+		return err
+	}
+	if _, _, err := s.resolveTrack(input.Track); err != nil {
+		return err
 	}
+
+	if depth := s.worker.cfg.QueueDepth; depth > 0 {
+		queued, err := s.repo.CountByStatus(ctx, domain.StatusQueued)
+		if err != nil {
+			return err
+		}
+		if queued >= depth {
+			return ErrQueueFull
+		}
+	}
+	return nil
 }
 
-func (s *evaluationService) CreateEvaluation(ctx context.Context, cvPath, reportPath string) (*domain.Evaluation, error) {
+func (s *evaluationService) CreateEvaluation(ctx context.Context, input CreateEvaluationInput) (*domain.Evaluation, error) {
+	jobDescription, err := s.resolveJobDescription(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	track, collection, err := s.resolveTrack(input.Track)
+	if err != nil {
+		return nil, err
+	}
+
 	eval := &domain.Evaluation{
-		ID:         uuid.New(),
-		Status:     domain.StatusQueued,
-		CVPath:     cvPath,     // Placeholder, will be filled from upload
-		ReportPath: reportPath, // Placeholder
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		ID:        uuid.New(),
+		Status:    domain.StatusQueued,
+		CVKey:     input.CVDocument.StorageKey,
+		ReportKey: input.ReportDocument.StorageKey,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+
+		CVDocumentID:     &input.CVDocument.ID,
+		ReportDocumentID: &input.ReportDocument.ID,
+
+		JobDescriptionID: input.JobDescriptionID,
+	}
+	if jobDescription != "" {
+		eval.JobDescription = &jobDescription
+	}
+	if track != "" {
+		eval.Track = &track
+		eval.Collection = &collection
 	}
 
-	err := s.repo.Create(ctx, eval)
+	err = s.repo.Create(ctx, eval, s.worker.cfg.QueueDepth)
+	if errors.Is(err, repository.ErrQueueFull) {
+		return nil, ErrQueueFull
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: Trigger the background processing (AI Pipeline) in a goroutine here
-	go s.processEvaluation(eval.ID)
+	// The job is persisted as queued; wake the worker so it is picked up immediately
+	s.worker.Notify()
 
 	return eval, nil
 }
@@ -57,58 +143,62 @@ func (s *evaluationService) GetEvaluationResult(ctx context.Context, id uuid.UUI
 	return s.repo.FindByID(ctx, id)
 }
 
-// processEvaluation runs the AI evaluation pipeline in the background
-func (s *evaluationService) processEvaluation(id uuid.UUID) {
-	log.Printf("Starting AI evaluation for job ID: %s", id)
-
-	ctx := context.Background()
-
-	// Update status to processing
-	eval, err := s.repo.FindByID(ctx, id)
-	if err != nil {
-		log.Printf("Error finding evaluation %s for processing: %v", id, err)
-		return
+// resolveTrack normalises a hiring track and returns the collection holding its guidelines;
+// both are empty if no track is given
+func (s *evaluationService) resolveTrack(input string) (track, collection string, err error) {
+	track = strings.ToLower(strings.TrimSpace(input))
+	if track == "" {
+		return "", "", nil
 	}
-
-	eval.Status = domain.StatusProcessing
-	err = s.repo.Update(ctx, eval)
-	if err != nil {
-		log.Printf("Error updating evaluation %s to processing: %v", id, err)
-		return
+	collection, ok := s.trackCollections[track]
+	if !ok {
+		return "", "", ErrUnknownTrack
 	}
+	return track, collection, nil
+}
 
-	// Run the AI pipeline
-	result, err := s.aiPipeline.ProcessEvaluation(ctx, eval.CVPath, eval.ReportPath)
-	if err != nil {
-		log.Printf("AI pipeline failed for evaluation %s: %v", id, err)
+// resolveJobDescription returns the job requirements text for the input, looking up a stored job description by ID
+func (s *evaluationService) resolveJobDescription(ctx context.Context, input CreateEvaluationInput) (string, error) {
+	inline := strings.TrimSpace(input.JobDescription)
 
-		// Update status to failed
-		eval.Status = domain.StatusFailed
-		s.repo.Update(ctx, eval)
-		return
+	if input.JobDescriptionID == nil {
+		if len(inline) > MaxJobDescriptionLength {
+			return "", ErrInvalidJobDescription
+		}
+		return inline, nil
+	}
+	if inline != "" {
+		return "", ErrConflictingJobDescription
 	}
 
-	// Convert result to JSON
-	resultJSON, err := json.Marshal(result)
+	jd, err := s.jdRepo.FindByID(ctx, *input.JobDescriptionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrJobDescriptionNotFound
+	}
 	if err != nil {
-		log.Printf("Error marshaling result for evaluation %s: %v", id, err)
-
-		// Update status to failed
-		eval.Status = domain.StatusFailed
-		s.repo.Update(ctx, eval)
-		return
+		return "", err
 	}
 
-	// Update evaluation with results
-	eval.Status = domain.StatusCompleted
-	raw := json.RawMessage(resultJSON)
-	eval.Result = &raw
+	return jd.Title + "\n\n" + jd.Description, nil
+}
 
-	err = s.repo.Update(ctx, eval)
+// CancelEvaluation stops a queued or processing evaluation. A queued evaluation is never
+// picked up; a running one is aborted, immediately if this instance is processing it and
+// otherwise at its worker's next heartbeat.
+func (s *evaluationService) CancelEvaluation(ctx context.Context, id uuid.UUID) (*domain.Evaluation, error) {
+	eval, err := s.repo.Cancel(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := s.repo.FindByID(ctx, id); errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEvaluationNotFound
+		} else if err != nil {
+			return nil, err
+		}
+		return nil, ErrEvaluationFinished
+	}
 	if err != nil {
-		log.Printf("Error updating evaluation %s to completed: %v", id, err)
-		return
+		return nil, err
 	}
 
-	log.Printf("Successfully completed AI evaluation for job ID: %s", id)
+	s.worker.Cancel(id)
+	return eval, nil
 }
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"aicvevaluator/internal/ai"
+	"aicvevaluator/internal/domain"
+	"aicvevaluator/internal/repository/repotest"
+	"aicvevaluator/internal/storage"
+	"aicvevaluator/internal/util"
+
+	"github.com/google/uuid"
+)
+
+// testEnv wires an evaluation service, worker and fake LLM over in-memory repositories
+type testEnv struct {
+	service EvaluationService
+	repo    *repotest.EvaluationRepo
+	jdRepo  *repotest.JobDescriptionRepo
+	worker  *Worker
+	llm     *ai.FakeProvider
+	cv      *domain.Document
+	report  *domain.Document
+}
+
+func newTestEnv(t *testing.T, faults string, queueDepth int) *testEnv {
+	t.Helper()
+	ctx := context.Background()
+
+	files, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploads := map[string]string{
+		"cv.txt":     "Jane Doe\n\nSkills\nGo, PostgreSQL, Docker\n\nExperience\nBackend engineer at Acme, 4 years",
+		"report.txt": "The project is a Go service with a durable queue, retries and a two-stage LLM pipeline.",
+	}
+	for key, content := range uploads {
+		if err := files.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	llm, err := ai.NewFakeProviderFromConfig("", faults)
+	if err != nil {
+		t.Fatalf("NewFakeProviderFromConfig(%q) error = %v", faults, err)
+	}
+
+	pipeline := ai.NewPipeline(files, util.NewFileReader(), nil, nil, nil, llm,
+		ai.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		ai.RetrievalPolicy{TopK: 2})
+
+	repo := repotest.NewEvaluationRepo()
+	jdRepo := repotest.NewJobDescriptionRepo()
+	worker := NewWorker(repo, pipeline, WorkerConfig{
+		Concurrency:       1,
+		QueueDepth:        queueDepth,
+		PollInterval:      10 * time.Millisecond,
+		LeaseDuration:     time.Minute,
+		HeartbeatInterval: time.Second,
+		MaxAttempts:       3,
+	})
+
+	return &testEnv{
+		service: NewEvaluationService(repo, jdRepo, worker, map[string]string{"backend": "guidelines_backend"}),
+		repo:    repo,
+		jdRepo:  jdRepo,
+		worker:  worker,
+		llm:     llm,
+		cv:      &domain.Document{ID: uuid.New(), StorageKey: "cv.txt"},
+		report:  &domain.Document{ID: uuid.New(), StorageKey: "report.txt"},
+	}
+}
+
+// start runs the worker until the test ends
+func (e *testEnv) start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.worker.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// wait polls the evaluation until it leaves the queue
+func (e *testEnv) wait(t *testing.T, id uuid.UUID) *domain.Evaluation {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		eval, err := e.service.GetEvaluationResult(context.Background(), id)
+		if err != nil {
+			t.Fatalf("GetEvaluationResult() error = %v", err)
+		}
+		if eval.Status != domain.StatusQueued && eval.Status != domain.StatusProcessing {
+			return eval
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("evaluation %s did not finish", id)
+	return nil
+}
+
+func TestEvaluationEndToEnd(t *testing.T) {
+	tests := []struct {
+		name        string
+		faults      string
+		wantStatus  domain.EvaluationStatus
+		wantCode    string
+		wantStage   string
+		wantCalls   []string // fake provider stages, in order
+		wantRetries int      // failed attempts recorded as retryable
+	}{
+		{
+			name:       "success",
+			wantStatus: domain.StatusCompleted,
+			wantCalls:  []string{ai.FakeStage1, ai.FakeStage2},
+		},
+		{
+			name:        "quota errors are retried",
+			faults:      "stage1=quota*2",
+			wantStatus:  domain.StatusCompleted,
+			wantCalls:   []string{ai.FakeStage1, ai.FakeStage1, ai.FakeStage1, ai.FakeStage2},
+			wantRetries: 2,
+		},
+		{
+			name:        "quota exhausted",
+			faults:      "stage2=quota*3",
+			wantStatus:  domain.StatusFailed,
+			wantCode:    ai.CodeQuotaExceeded,
+			wantStage:   ai.StageStage2,
+			wantCalls:   []string{ai.FakeStage1, ai.FakeStage2, ai.FakeStage2, ai.FakeStage2},
+			wantRetries: 3,
+		},
+		{
+			name:       "model not found is not retried",
+			faults:     "stage2=model_not_found",
+			wantStatus: domain.StatusFailed,
+			wantCode:   ai.CodeModelNotFound,
+			wantStage:  ai.StageStage2,
+			wantCalls:  []string{ai.FakeStage1, ai.FakeStage2},
+		},
+		{
+			name:       "safety block",
+			faults:     "stage1=safety",
+			wantStatus: domain.StatusFailed,
+			wantCode:   ai.CodeSafetyBlocked,
+			wantStage:  ai.StageStage1,
+			wantCalls:  []string{ai.FakeStage1},
+		},
+		{
+			name:       "invalid JSON is repaired",
+			faults:     "stage1=malformed_json",
+			wantStatus: domain.StatusCompleted,
+			wantCalls:  []string{ai.FakeStage1, ai.FakeJSON, ai.FakeStage2},
+		},
+		{
+			name:       "invalid JSON that cannot be repaired",
+			faults:     "stage2=malformed_json,json=malformed_json",
+			wantStatus: domain.StatusFailed,
+			wantCode:   ai.CodeInvalidResponse,
+			wantStage:  ai.StageParse,
+			wantCalls:  []string{ai.FakeStage1, ai.FakeStage2, ai.FakeJSON},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, tt.faults, 0)
+			env.start(t)
+
+			created, err := env.service.CreateEvaluation(context.Background(), CreateEvaluationInput{
+				CVDocument:     env.cv,
+				ReportDocument: env.report,
+				JobDescription: "Backend engineer, Go and PostgreSQL",
+			})
+			if err != nil {
+				t.Fatalf("CreateEvaluation() error = %v", err)
+			}
+			if created.Status != domain.StatusQueued {
+				t.Errorf("created status = %s, want queued", created.Status)
+			}
+
+			eval := env.wait(t, created.ID)
+			if eval.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s (error: %v)", eval.Status, tt.wantStatus, deref(eval.ErrorMessage))
+			}
+
+			var stages []string
+			for _, call := range env.llm.Calls() {
+				stages = append(stages, call.Stage)
+			}
+			if strings.Join(stages, ",") != strings.Join(tt.wantCalls, ",") {
+				t.Errorf("LLM calls = %v, want %v", stages, tt.wantCalls)
+			}
+
+			var attempts []ai.Attempt
+			if err := json.Unmarshal(*eval.LLMAttempts, &attempts); err != nil {
+				t.Fatalf("invalid llm_attempts: %v", err)
+			}
+			if len(attempts) != len(tt.wantCalls) {
+				t.Errorf("recorded %d attempts, want %d", len(attempts), len(tt.wantCalls))
+			}
+			retries := 0
+			for _, a := range attempts {
+				if a.Retryable {
+					retries++
+				}
+			}
+			if retries != tt.wantRetries {
+				t.Errorf("recorded %d retryable failures, want %d", retries, tt.wantRetries)
+			}
+
+			if eval.LeaseOwner != nil {
+				t.Errorf("lease owner = %s, want none", *eval.LeaseOwner)
+			}
+
+			if tt.wantStatus == domain.StatusFailed {
+				if deref(eval.ErrorCode) != tt.wantCode || deref(eval.FailedStage) != tt.wantStage {
+					t.Errorf("failure = %s at %s, want %s at %s", deref(eval.ErrorCode), deref(eval.FailedStage), tt.wantCode, tt.wantStage)
+				}
+				if eval.Result != nil {
+					t.Errorf("failed evaluation has a result: %s", *eval.Result)
+				}
+				return
+			}
+
+			var result ai.EvaluationResult
+			if err := json.Unmarshal(*eval.Result, &result); err != nil {
+				t.Fatalf("invalid result: %v", err)
+			}
+			if result.CVMatchRate != 0.75 || result.ProjectScore != 7.5 {
+				t.Errorf("result = %+v, want the canned evaluation", result)
+			}
+			if eval.Analysis == nil || !bytes.Contains(*eval.Analysis, []byte(`"cv_experience_level":"mid"`)) {
+				t.Errorf("analysis = %v, want the canned Stage 1 analysis", eval.Analysis)
+			}
+			if eval.ErrorCode != nil {
+				t.Errorf("completed evaluation has error code %s", *eval.ErrorCode)
+			}
+		})
+	}
+}
+
+func TestCreateEvaluationValidation(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, "", 1)
+
+	jd := &domain.JobDescription{ID: uuid.New(), Title: "Backend Engineer", Description: "Go and PostgreSQL"}
+	env.jdRepo.Create(ctx, jd)
+	missing := uuid.New()
+
+	tests := []struct {
+		name  string
+		input CreateEvaluationInput
+		want  error
+	}{
+		{"conflicting job description", CreateEvaluationInput{JobDescriptionID: &jd.ID, JobDescription: "inline"}, ErrConflictingJobDescription},
+		{"unknown job description", CreateEvaluationInput{JobDescriptionID: &missing}, ErrJobDescriptionNotFound},
+		{"job description too long", CreateEvaluationInput{JobDescription: strings.Repeat("x", MaxJobDescriptionLength+1)}, ErrInvalidJobDescription},
+		{"unknown track", CreateEvaluationInput{Track: "frontend"}, ErrUnknownTrack},
+	}
+	for _, tt := range tests {
+		// The same checks run before and after the files are stored
+		if err := env.service.CheckEvaluation(ctx, tt.input); !errors.Is(err, tt.want) {
+			t.Errorf("%s: CheckEvaluation() error = %v, want %v", tt.name, err, tt.want)
+		}
+		tt.input.CVDocument, tt.input.ReportDocument = env.cv, env.report
+		if _, err := env.service.CreateEvaluation(ctx, tt.input); !errors.Is(err, tt.want) {
+			t.Errorf("%s: CreateEvaluation() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if err := env.service.CheckEvaluation(ctx, CreateEvaluationInput{Track: "backend"}); err != nil {
+		t.Errorf("CheckEvaluation() error = %v", err)
+	}
+
+	eval, err := env.service.CreateEvaluation(ctx, CreateEvaluationInput{
+		CVDocument: env.cv, ReportDocument: env.report, JobDescriptionID: &jd.ID, Track: " Backend ",
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluation() error = %v", err)
+	}
+	if deref(eval.JobDescription) != "Backend Engineer\n\nGo and PostgreSQL" {
+		t.Errorf("job description = %q, want the stored one", deref(eval.JobDescription))
+	}
+	if deref(eval.Track) != "backend" || deref(eval.Collection) != "guidelines_backend" {
+		t.Errorf("track = %q, collection = %q", deref(eval.Track), deref(eval.Collection))
+	}
+
+	// The worker is not running, so the first evaluation fills the queue
+	if err := env.service.CheckEvaluation(ctx, CreateEvaluationInput{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("CheckEvaluation() on a full queue error = %v, want ErrQueueFull", err)
+	}
+	_, err = env.service.CreateEvaluation(ctx, CreateEvaluationInput{CVDocument: env.cv, ReportDocument: env.report})
+	if !errors.Is(err, ErrQueueFull) {
+		t.Errorf("CreateEvaluation() on a full queue error = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestCancelEvaluation(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, "", 0)
+
+	eval, err := env.service.CreateEvaluation(ctx, CreateEvaluationInput{CVDocument: env.cv, ReportDocument: env.report})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancelled, err := env.service.CancelEvaluation(ctx, eval.ID)
+	if err != nil || cancelled.Status != domain.StatusCancelled {
+		t.Fatalf("CancelEvaluation() = %v, %v; want cancelled", cancelled, err)
+	}
+	if _, err := env.service.CancelEvaluation(ctx, eval.ID); !errors.Is(err, ErrEvaluationFinished) {
+		t.Errorf("second CancelEvaluation() error = %v, want ErrEvaluationFinished", err)
+	}
+	if _, err := env.service.CancelEvaluation(ctx, uuid.New()); !errors.Is(err, ErrEvaluationNotFound) {
+		t.Errorf("CancelEvaluation() of a missing evaluation error = %v, want ErrEvaluationNotFound", err)
+	}
+
+	// A cancelled evaluation is never picked up
+	if env.worker.processNext(ctx) {
+		t.Error("worker claimed a cancelled evaluation")
+	}
+	if calls := env.llm.Calls(); len(calls) != 0 {
+		t.Errorf("LLM was called %d times for a cancelled evaluation", len(calls))
+	}
+}
+
+func TestFinishAfterLostLease(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv(t, "", 0)
+
+	eval, err := env.service.CreateEvaluation(ctx, CreateEvaluationInput{CVDocument: env.cv, ReportDocument: env.report})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimed, err := env.repo.ClaimNext(ctx, env.worker.id, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another worker reclaims the job; this run's result must not overwrite it
+	if err := env.repo.Release(ctx, eval.ID, env.worker.id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.repo.ClaimNext(ctx, "other-worker", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	result := json.RawMessage(`{}`)
+	if env.worker.finish(claimed, domain.StatusCompleted, &result) {
+		t.Error("finish() stored a result without holding the lease")
+	}
+	stored, _ := env.repo.FindByID(ctx, eval.ID)
+	if stored.Status != domain.StatusProcessing || stored.Result != nil {
+		t.Errorf("evaluation = %s with result %v, want it untouched", stored.Status, stored.Result)
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"aicvevaluator/internal/domain"
+	"aicvevaluator/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// MaxJobDescriptionLength caps the job description text sent to the LLM
+const MaxJobDescriptionLength = 20000
+
+var (
+	// ErrJobDescriptionNotFound is returned when a job description does not exist
+	ErrJobDescriptionNotFound = errors.New("job description not found")
+
+	// ErrInvalidJobDescription is returned when a job description fails validation
+	ErrInvalidJobDescription = fmt.Errorf("job description requires a title and a description of at most %d characters", MaxJobDescriptionLength)
+)
+
+// JobDescriptionService defines the business logic for managing job descriptions
+type JobDescriptionService interface {
+	Create(ctx context.Context, title, description string) (*domain.JobDescription, error)
+	Get(ctx context.Context, id uuid.UUID) (*domain.JobDescription, error)
+	List(ctx context.Context) ([]domain.JobDescription, error)
+	Update(ctx context.Context, id uuid.UUID, title, description string) (*domain.JobDescription, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type jobDescriptionService struct {
+	repo repository.JobDescriptionRepository
+}
+
+// NewJobDescriptionService creates a new instance of the service
+func NewJobDescriptionService(repo repository.JobDescriptionRepository) JobDescriptionService {
+	return &jobDescriptionService{repo: repo}
+}
+
+func (s *jobDescriptionService) Create(ctx context.Context, title, description string) (*domain.JobDescription, error) {
+	title, description, err := validateJobDescription(title, description)
+	if err != nil {
+		return nil, err
+	}
+
+	jd := &domain.JobDescription{
+		ID:          uuid.New(),
+		Title:       title,
+		Description: description,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := s.repo.Create(ctx, jd); err != nil {
+		return nil, err
+	}
+	return jd, nil
+}
+
+func (s *jobDescriptionService) Get(ctx context.Context, id uuid.UUID) (*domain.JobDescription, error) {
+	jd, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobDescriptionNotFound
+	}
+	return jd, err
+}
+
+func (s *jobDescriptionService) List(ctx context.Context) ([]domain.JobDescription, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *jobDescriptionService) Update(ctx context.Context, id uuid.UUID, title, description string) (*domain.JobDescription, error) {
+	title, description, err := validateJobDescription(title, description)
+	if err != nil {
+		return nil, err
+	}
+
+	jd := &domain.JobDescription{ID: id, Title: title, Description: description}
+	err = s.repo.Update(ctx, jd)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobDescriptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, id)
+}
+
+func (s *jobDescriptionService) Delete(ctx context.Context, id uuid.UUID) error {
+	err := s.repo.Delete(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrJobDescriptionNotFound
+	}
+	return err
+}
+
+// validateJobDescription trims the fields and checks they are present and within limits
+func validateJobDescription(title, description string) (string, string, error) {
+	title = strings.TrimSpace(title)
+	description = strings.TrimSpace(description)
+
+	if title == "" || description == "" || len(description) > MaxJobDescriptionLength {
+		return "", "", ErrInvalidJobDescription
+	}
+	return title, description, nil
+}
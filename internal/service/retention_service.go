@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"aicvevaluator/internal/domain"
+	"aicvevaluator/internal/repository"
+	"aicvevaluator/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrEvaluationInProgress is returned when deleting an evaluation a worker is processing
+	ErrEvaluationInProgress = errors.New("evaluation is being processed; cancel it or retry once it has finished")
+
+	// ErrFilesInUse is returned when deleting an evaluation whose files a queued or processing
+	// evaluation also uses
+	ErrFilesInUse = errors.New("the evaluation's files are used by another evaluation that is queued or being processed; cancel it or retry once it has finished")
+)
+
+// purgeBatchSize is the number of rows handled per query by the janitor
+const purgeBatchSize = 100
+
+// unusedDocumentGrace is how long a stored file may stay unreferenced, e.g. between being
+// uploaded and its evaluation being created, before the janitor deletes it
+const unusedDocumentGrace = time.Hour
+
+// RetentionPolicy sets how long candidate data is kept; a zero period keeps it forever
+type RetentionPolicy struct {
+	Files         time.Duration // uploaded CVs and reports
+	Results       time.Duration // results, analyses and other candidate data in evaluations
+	PurgeInterval time.Duration
+}
+
+// RetentionService deletes candidate data once its retention period expires, or on request.
+// Every deletion is recorded in the deletion audit log.
+type RetentionService struct {
+	repo   repository.RetentionRepository
+	files  storage.Storage
+	policy RetentionPolicy
+}
+
+// NewRetentionService creates a new retention service
+func NewRetentionService(repo repository.RetentionRepository, files storage.Storage, policy RetentionPolicy) *RetentionService {
+	return &RetentionService{repo: repo, files: files, policy: policy}
+}
+
+// Run purges expired data every PurgeInterval until ctx is cancelled
+func (s *RetentionService) Run(ctx context.Context) {
+	log.Printf("Retention janitor started (files: %s, results: %s)", retentionString(s.policy.Files), retentionString(s.policy.Results))
+
+	ticker := time.NewTicker(s.policy.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		s.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Retention janitor stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge deletes expired files, files no evaluation uses any more and expired results
+func (s *RetentionService) Purge(ctx context.Context) {
+	now := time.Now()
+
+	if s.policy.Files > 0 {
+		if err := s.purgeFiles(ctx, now.Add(-s.policy.Files)); err != nil && ctx.Err() == nil {
+			log.Printf("Error purging expired files: %v", err)
+		}
+	}
+
+	if err := s.purgeDocuments(ctx, now.Add(-unusedDocumentGrace)); err != nil && ctx.Err() == nil {
+		log.Printf("Error purging unused files: %v", err)
+	}
+
+	if s.policy.Results > 0 {
+		if err := s.redactResults(ctx, now.Add(-s.policy.Results)); err != nil && ctx.Err() == nil {
+			log.Printf("Error redacting expired results: %v", err)
+		}
+	}
+}
+
+// purgeFiles detaches the files of finished evaluations created before cutoff. Deduplicated
+// files are deleted by purgeDocuments once no evaluation uses them.
+func (s *RetentionService) purgeFiles(ctx context.Context, cutoff time.Time) error {
+	for {
+		evals, err := s.repo.FindExpiredFiles(ctx, cutoff, purgeBatchSize)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]uuid.UUID, 0, len(evals))
+		for _, eval := range evals {
+			if err := s.deleteUndocumentedFiles(ctx, &eval); err != nil {
+				log.Printf("Error deleting files of evaluation %s: %v", eval.ID, err)
+				continue
+			}
+			ids = append(ids, eval.ID)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		n, err := s.repo.DetachFiles(ctx, ids, domain.DeletionReasonRetention)
+		if err != nil {
+			return err
+		}
+		log.Printf("Deleted the files of %d evaluation(s) past retention", n)
+
+		if len(evals) < purgeBatchSize {
+			return nil
+		}
+	}
+}
+
+// purgeDocuments deletes the files of documents no evaluation uses that were last uploaded
+// before cutoff. The file goes first, so a failed delete leaves the document to retry later.
+func (s *RetentionService) purgeDocuments(ctx context.Context, cutoff time.Time) error {
+	for {
+		docs, err := s.repo.FindUnusedDocuments(ctx, cutoff, purgeBatchSize)
+		if err != nil {
+			return err
+		}
+
+		var deleted int
+		for _, doc := range docs {
+			if err := s.files.Delete(ctx, doc.StorageKey); err != nil {
+				log.Printf("Error deleting file of document %s: %v", doc.ID, err)
+				continue
+			}
+			// An upload of the same content in the meantime keeps the row; its file is
+			// restored on the next upload, and its text is served from the cache
+			ok, err := s.repo.DeleteDocument(ctx, doc.ID, domain.DeletionReasonRetention)
+			if err != nil {
+				return err
+			}
+			if ok {
+				deleted++
+			}
+		}
+		if deleted > 0 {
+			log.Printf("Deleted %d unused file(s)", deleted)
+		}
+
+		if len(docs) < purgeBatchSize || deleted == 0 {
+			return nil
+		}
+	}
+}
+
+// redactResults clears the results of finished evaluations created before cutoff
+func (s *RetentionService) redactResults(ctx context.Context, cutoff time.Time) error {
+	for {
+		n, err := s.repo.RedactResults(ctx, cutoff, purgeBatchSize)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			log.Printf("Redacted %d evaluation result(s) past retention", n)
+		}
+		if n < purgeBatchSize {
+			return nil
+		}
+	}
+}
+
+// DeleteEvaluation deletes an evaluation with its result, files and their cached text, e.g. at
+// a candidate's request. Files are deleted even if other evaluations uploaded the same content;
+// those evaluations lose their reference to them, as if the files had expired.
+//
+// The evaluation row goes first, so no worker can pick it up once its files are being deleted.
+// A file that then fails to delete stays recorded as an unused document, which the janitor
+// deletes on a later run.
+func (s *RetentionService) DeleteEvaluation(ctx context.Context, id uuid.UUID) error {
+	eval, err := s.repo.DeleteEvaluation(ctx, id, domain.DeletionReasonRequest)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrEvaluationNotFound
+	case errors.Is(err, repository.ErrEvaluationInProgress):
+		return ErrEvaluationInProgress
+	case errors.Is(err, repository.ErrDocumentInUse):
+		return ErrFilesInUse
+	case err != nil:
+		return err
+	}
+
+	var failed error
+	files := []struct {
+		key   string
+		docID *uuid.UUID
+	}{{eval.CVKey, eval.CVDocumentID}, {eval.ReportKey, eval.ReportDocumentID}}
+	for i, file := range files {
+		if file.key == "" || (i == 1 && file.key == files[0].key) {
+			continue // the same content may be uploaded as both CV and report
+		}
+		if err := s.files.Delete(ctx, file.key); err != nil {
+			log.Printf("Error deleting file %s of deleted evaluation %s: %v", file.key, id, err)
+			failed = err
+			continue
+		}
+		if file.docID == nil {
+			continue
+		}
+
+		deleted, err := s.repo.DeleteDocument(ctx, *file.docID, domain.DeletionReasonRequest)
+		if err != nil {
+			log.Printf("Error deleting document %s of deleted evaluation %s: %v", *file.docID, id, err)
+			failed = err
+			continue
+		}
+		if !deleted {
+			// The same content was uploaded again meanwhile; that upload restores the file
+			log.Printf("Document %s was reused while evaluation %s was deleted, keeping it", *file.docID, id)
+		}
+	}
+	if failed != nil {
+		return fmt.Errorf("evaluation deleted, but not all of its files: %w", failed)
+	}
+	return nil
+}
+
+// deleteUndocumentedFiles deletes the files of an evaluation created before uploads were
+// recorded as documents; deduplicated files are left to purgeDocuments
+func (s *RetentionService) deleteUndocumentedFiles(ctx context.Context, eval *domain.Evaluation) error {
+	if eval.CVDocumentID == nil && eval.CVKey != "" {
+		if err := s.files.Delete(ctx, eval.CVKey); err != nil {
+			return err
+		}
+	}
+	if eval.ReportDocumentID == nil && eval.ReportKey != "" {
+		if err := s.files.Delete(ctx, eval.ReportKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retentionString formats a retention period for logging
+func retentionString(d time.Duration) string {
+	if d == 0 {
+		return "kept forever"
+	}
+	return d.String()
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"aicvevaluator/internal/domain"
+	"aicvevaluator/internal/repository"
+	"aicvevaluator/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// memoryRetentionRepo is a RetentionRepository whose DeleteEvaluation returns a fixed
+// evaluation or error, recording the documents deleted afterwards
+type memoryRetentionRepo struct {
+	eval      *domain.Evaluation
+	deleteErr error
+	docs      []uuid.UUID
+}
+
+func (r *memoryRetentionRepo) FindExpiredFiles(ctx context.Context, cutoff time.Time, limit int) ([]domain.Evaluation, error) {
+	return nil, nil
+}
+
+func (r *memoryRetentionRepo) DetachFiles(ctx context.Context, ids []uuid.UUID, reason string) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryRetentionRepo) FindUnusedDocuments(ctx context.Context, cutoff time.Time, limit int) ([]domain.Document, error) {
+	return nil, nil
+}
+
+func (r *memoryRetentionRepo) DeleteDocument(ctx context.Context, id uuid.UUID, reason string) (bool, error) {
+	r.docs = append(r.docs, id)
+	return true, nil
+}
+
+func (r *memoryRetentionRepo) RedactResults(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryRetentionRepo) DeleteEvaluation(ctx context.Context, id uuid.UUID, reason string) (*domain.Evaluation, error) {
+	if r.deleteErr != nil {
+		return nil, r.deleteErr
+	}
+	return r.eval, nil
+}
+
+// failingStorage fails to delete the key bad
+type failingStorage struct {
+	storage.Storage
+	bad string
+}
+
+func (s *failingStorage) Delete(ctx context.Context, key string) error {
+	if key == s.bad {
+		return errors.New("storage unavailable")
+	}
+	return s.Storage.Delete(ctx, key)
+}
+
+func TestDeleteEvaluation(t *testing.T) {
+	cvDoc, reportDoc := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		cvKey     string
+		reportKey string
+		deleteErr error
+		badKey    string
+		wantErr   error
+		wantKept  []string // files left in storage
+		wantDocs  []uuid.UUID
+	}{
+		{
+			name:  "deletes files and documents",
+			cvKey: "cv.pdf", reportKey: "report.pdf",
+			wantDocs: []uuid.UUID{cvDoc, reportDoc},
+		},
+		{
+			name:  "missing evaluation",
+			cvKey: "cv.pdf", reportKey: "report.pdf",
+			deleteErr: sql.ErrNoRows,
+			wantErr:   ErrEvaluationNotFound,
+			wantKept:  []string{"cv.pdf", "report.pdf"},
+		},
+		{
+			// e.g. a queued evaluation claimed by a worker before the delete locked it
+			name:  "evaluation in progress",
+			cvKey: "cv.pdf", reportKey: "report.pdf",
+			deleteErr: repository.ErrEvaluationInProgress,
+			wantErr:   ErrEvaluationInProgress,
+			wantKept:  []string{"cv.pdf", "report.pdf"},
+		},
+		{
+			name:  "files used by a queued evaluation",
+			cvKey: "cv.pdf", reportKey: "report.pdf",
+			deleteErr: repository.ErrDocumentInUse,
+			wantErr:   ErrFilesInUse,
+			wantKept:  []string{"cv.pdf", "report.pdf"},
+		},
+		{
+			name:  "failed file delete keeps its document for the janitor",
+			cvKey: "cv.pdf", reportKey: "report.pdf",
+			badKey:   "cv.pdf",
+			wantKept: []string{"cv.pdf"},
+			wantDocs: []uuid.UUID{reportDoc},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			local, err := storage.NewLocalStorage(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range []string{tt.cvKey, tt.reportKey} {
+				if err := local.Put(ctx, key, strings.NewReader(key), int64(len(key)), ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			eval := &domain.Evaluation{
+				ID:     uuid.New(),
+				Status: domain.StatusQueued,
+				CVKey:  tt.cvKey, ReportKey: tt.reportKey,
+				CVDocumentID: &cvDoc, ReportDocumentID: &reportDoc,
+			}
+			repo := &memoryRetentionRepo{eval: eval, deleteErr: tt.deleteErr}
+			retention := NewRetentionService(repo, &failingStorage{Storage: local, bad: tt.badKey}, RetentionPolicy{})
+
+			err = retention.DeleteEvaluation(ctx, eval.ID)
+			switch {
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("DeleteEvaluation() error = %v, want %v", err, tt.wantErr)
+			case tt.wantErr == nil && tt.badKey == "" && err != nil:
+				t.Errorf("DeleteEvaluation() error = %v", err)
+			case tt.badKey != "" && err == nil:
+				t.Error("DeleteEvaluation() hid a failed file delete")
+			}
+
+			for _, key := range []string{tt.cvKey, tt.reportKey} {
+				_, statErr := local.Stat(ctx, key)
+				kept := statErr == nil
+				want := false
+				for _, k := range tt.wantKept {
+					want = want || k == key
+				}
+				if kept != want {
+					t.Errorf("file %s kept = %v, want %v", key, kept, want)
+				}
+			}
+			if len(repo.docs) != len(tt.wantDocs) {
+				t.Fatalf("deleted documents %v, want %v", repo.docs, tt.wantDocs)
+			}
+			for i := range repo.docs {
+				if repo.docs[i] != tt.wantDocs[i] {
+					t.Errorf("deleted documents %v, want %v", repo.docs, tt.wantDocs)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteEvaluationSharedFile(t *testing.T) {
+	// The same content uploaded as both CV and report is one file and one document
+	ctx := context.Background()
+	local, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := local.Put(ctx, "doc.pdf", strings.NewReader("x"), 1, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	docID := uuid.New()
+	repo := &memoryRetentionRepo{eval: &domain.Evaluation{
+		ID: uuid.New(), Status: domain.StatusCompleted, CVKey: "doc.pdf", ReportKey: "doc.pdf",
+		CVDocumentID: &docID, ReportDocumentID: &docID,
+	}}
+	if err := NewRetentionService(repo, local, RetentionPolicy{}).DeleteEvaluation(ctx, repo.eval.ID); err != nil {
+		t.Fatalf("DeleteEvaluation() error = %v", err)
+	}
+	if _, err := local.Stat(ctx, "doc.pdf"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Stat() error = %v, want ErrNotFound", err)
+	}
+	if len(repo.docs) != 1 || repo.docs[0] != docID {
+		t.Errorf("deleted documents %v, want only %s", repo.docs, docID)
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"aicvevaluator/internal/util"
+)
+
+// Upload validation failure codes, returned to clients in UploadError.Code
+const (
+	UploadEmptyFile         = "empty_file"
+	UploadFileTooLarge      = "file_too_large"
+	UploadUnsupportedFormat = "unsupported_format"
+	UploadEncryptedPDF      = "encrypted_pdf"
+	UploadTooManyPages      = "too_many_pages"
+	UploadInsufficientText  = "insufficient_text"
+	UploadUnreadable        = "unreadable_file"
+)
+
+// UploadLimits are the checks applied to every uploaded document
+type UploadLimits struct {
+	MaxFileSize   int64 // bytes
+	MaxPages      int   // 0 for no limit
+	MinTextLength int   // characters of extracted text
+}
+
+// UploadError describes why an uploaded file was rejected
+type UploadError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"error"`
+}
+
+func (e *UploadError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// UploadValidator checks uploads synchronously, so unreadable files are rejected before a job is queued
+type UploadValidator struct {
+	fileReader *util.FileReader
+	limits     UploadLimits
+}
+
+// NewUploadValidator creates a validator enforcing limits
+func NewUploadValidator(fileReader *util.FileReader, limits UploadLimits) *UploadValidator {
+	return &UploadValidator{fileReader: fileReader, limits: limits}
+}
+
+// MaxFileSize returns the largest accepted file in bytes
+func (v *UploadValidator) MaxFileSize() int64 {
+	return v.limits.MaxFileSize
+}
+
+// CheckSize rejects empty or oversized files before their content is read
+func (v *UploadValidator) CheckSize(field string, size int64) error {
+	switch {
+	case size == 0:
+		return &UploadError{Field: field, Code: UploadEmptyFile, Message: "file is empty"}
+	case size > v.limits.MaxFileSize:
+		return &UploadError{
+			Field:   field,
+			Code:    UploadFileTooLarge,
+			Message: fmt.Sprintf("file is larger than the %d byte limit", v.limits.MaxFileSize),
+		}
+	}
+	return nil
+}
+
+// Validate sniffs the file's format and extracts its text, rejecting files that cannot be
+// evaluated. The page count is checked before any text is extracted. It returns an
+// *UploadError for every validation failure.
+func (v *UploadValidator) Validate(field, filename string, data []byte) (*util.Document, error) {
+	if err := v.CheckSize(field, int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	pages, err := v.fileReader.CountPages(data, filename)
+	if err != nil {
+		return nil, readError(field, err)
+	}
+	if v.limits.MaxPages > 0 && pages > v.limits.MaxPages {
+		return nil, &UploadError{
+			Field:   field,
+			Code:    UploadTooManyPages,
+			Message: fmt.Sprintf("document has %d pages, the limit is %d", pages, v.limits.MaxPages),
+		}
+	}
+
+	doc, err := v.fileReader.ReadBytes(data, filename)
+	if err != nil {
+		return nil, readError(field, err)
+	}
+
+	if length := utf8.RuneCountInString(strings.TrimSpace(doc.Text)); length < v.limits.MinTextLength {
+		return nil, &UploadError{
+			Field:   field,
+			Code:    UploadInsufficientText,
+			Message: fmt.Sprintf("only %d characters of text could be extracted, at least %d are required; scanned documents are not supported", length, v.limits.MinTextLength),
+		}
+	}
+
+	return doc, nil
+}
+
+// readError turns a failure to read an upload into an *UploadError
+func readError(field string, err error) *UploadError {
+	switch {
+	case errors.Is(err, util.ErrUnsupportedFormat):
+		return &UploadError{
+			Field:   field,
+			Code:    UploadUnsupportedFormat,
+			Message: fmt.Sprintf("%v; supported formats are PDF, DOCX, ODT, RTF, HTML, Markdown and plain text", err),
+		}
+	case errors.Is(err, util.ErrEncryptedPDF):
+		return &UploadError{Field: field, Code: UploadEncryptedPDF, Message: "PDF is encrypted or password protected"}
+	case errors.Is(err, util.ErrTooManyPages):
+		return &UploadError{Field: field, Code: UploadTooManyPages, Message: err.Error()}
+	default:
+		return &UploadError{Field: field, Code: UploadUnreadable, Message: fmt.Sprintf("text could not be extracted: %v", err)}
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"aicvevaluator/internal/util"
+)
+
+// pagesPDF returns a PDF declaring count pages but holding none of them
+func pagesPDF(count int) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [3 0 R] /Count %d >>", count),
+	}
+
+	var b strings.Builder
+	b.WriteString("%PDF-1.4\n")
+	var offsets []int
+	for i, obj := range objects {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return []byte(b.String())
+}
+
+func TestUploadValidator(t *testing.T) {
+	validator := NewUploadValidator(util.NewFileReader(), UploadLimits{MaxFileSize: 1 << 20, MaxPages: 20, MinTextLength: 20})
+	text := []byte("Jane Doe, backend engineer with five years of Go experience")
+
+	tests := []struct {
+		name     string
+		filename string
+		data     []byte
+		wantCode string
+	}{
+		{"plain text", "cv.txt", text, ""},
+		{"empty", "cv.txt", nil, UploadEmptyFile},
+		{"too large", "cv.txt", make([]byte, 1<<20+1), UploadFileTooLarge},
+		{"too little text", "cv.txt", []byte("Jane Doe"), UploadInsufficientText},
+		{"unsupported", "cv.bin", []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00"), UploadUnsupportedFormat},
+		{"over the page limit", "cv.pdf", pagesPDF(21), UploadTooManyPages},
+		{"huge page count", "cv.pdf", pagesPDF(20000000), UploadTooManyPages},
+		{"unreadable PDF", "cv.pdf", []byte("%PDF-1.4\nnot really a PDF"), UploadUnreadable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			doc, err := validator.Validate("cv", tt.filename, tt.data)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Validate() took %s", elapsed)
+			}
+
+			if tt.wantCode == "" {
+				if err != nil || doc == nil {
+					t.Fatalf("Validate() = %v, %v; want a document", doc, err)
+				}
+				return
+			}
+			var uploadErr *UploadError
+			if !errors.As(err, &uploadErr) || uploadErr.Code != tt.wantCode || uploadErr.Field != "cv" {
+				t.Errorf("Validate() error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestUploadValidatorHardPageCap(t *testing.T) {
+	// Without a configured limit, extraction still stops at util.MaxPDFPages
+	validator := NewUploadValidator(util.NewFileReader(), UploadLimits{MaxFileSize: 1 << 20})
+
+	_, err := validator.Validate("cv", "cv.pdf", pagesPDF(util.MaxPDFPages+1))
+	var uploadErr *UploadError
+	if !errors.As(err, &uploadErr) || uploadErr.Code != UploadTooManyPages {
+		t.Errorf("Validate() error = %v, want code %s", err, UploadTooManyPages)
+	}
+}
@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"aicvevaluator/internal/ai"
+	"aicvevaluator/internal/domain"
+	"aicvevaluator/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// WorkerConfig controls how the worker polls and leases jobs from the evaluations table
+type WorkerConfig struct {
+	Concurrency       int // number of jobs processed in parallel
+	QueueDepth        int // maximum number of queued jobs, 0 means unlimited
+	PollInterval      time.Duration
+	LeaseDuration     time.Duration
+	HeartbeatInterval time.Duration
+	MaxAttempts       int
+}
+
+// evaluationMetadata is stored with a completed evaluation
+type evaluationMetadata struct {
+	// Redactions lists the personal details masked before prompting, without their values;
+	// null when redaction is disabled
+	Redactions []ai.RedactionSummary `json:"redactions"`
+
+	// FailedPages lists, by document, the pages whose text could not be extracted and were
+	// left out of the evaluation
+	FailedPages map[string][]int `json:"failed_pages,omitempty"`
+}
+
+// Worker processes queued evaluations with a bounded pool of goroutines. Jobs are
+// leased from the database so that an evaluation interrupted by a crash or deploy
+// is picked up again on restart.
+type Worker struct {
+	id         string
+	repo       repository.EvaluationRepository
+	aiPipeline *ai.Pipeline
+	cfg        WorkerConfig
+	wake       chan struct{}
+
+	mu      sync.Mutex
+	running map[uuid.UUID]context.CancelCauseFunc // jobs being processed by this worker
+}
+
+// NewWorker creates a new queue worker
+func NewWorker(repo repository.EvaluationRepository, aiPipeline *ai.Pipeline, cfg WorkerConfig) *Worker {
+	hostname, _ := os.Hostname()
+
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+
+	return &Worker{
+		id:         fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()[:8]),
+		repo:       repo,
+		aiPipeline: aiPipeline,
+		cfg:        cfg,
+		wake:       make(chan struct{}, cfg.Concurrency),
+		running:    make(map[uuid.UUID]context.CancelCauseFunc),
+	}
+}
+
+// Notify wakes the worker so a newly queued job is picked up without waiting for the next poll
+func (w *Worker) Notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run starts the worker pool and blocks until ctx is cancelled and all running jobs have stopped
+func (w *Worker) Run(ctx context.Context) {
+	log.Printf("Queue worker %s started with %d worker(s)", w.id, w.cfg.Concurrency)
+
+	w.reclaimStale(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.poll(ctx)
+		}()
+	}
+
+	reclaimTicker := time.NewTicker(w.cfg.LeaseDuration)
+	defer reclaimTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			log.Printf("Queue worker %s stopped", w.id)
+			return
+		case <-reclaimTicker.C:
+			w.reclaimStale(ctx)
+		}
+	}
+}
+
+// poll is the loop run by each goroutine in the pool
+func (w *Worker) poll(ctx context.Context) {
+	pollTicker := time.NewTicker(w.cfg.PollInterval)
+	defer pollTicker.Stop()
+
+	for {
+		// Drain the queue before waiting again
+		for w.processNext(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-pollTicker.C:
+		case <-w.wake:
+		}
+	}
+}
+
+// Cancel aborts the evaluation if this worker is processing it, and reports whether it was.
+// Evaluations processed by other workers stop at their next heartbeat.
+func (w *Worker) Cancel(id uuid.UUID) bool {
+	w.mu.Lock()
+	cancel, ok := w.running[id]
+	w.mu.Unlock()
+
+	if ok {
+		cancel(repository.ErrEvaluationCancelled)
+	}
+	return ok
+}
+
+// reclaimStale re-queues jobs whose worker stopped sending heartbeats
+func (w *Worker) reclaimStale(ctx context.Context) {
+	n, err := w.repo.ReclaimStale(ctx, w.cfg.MaxAttempts)
+	if err != nil {
+		log.Printf("Error reclaiming stale evaluations: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Reclaimed %d stale evaluation(s)", n)
+	}
+}
+
+// processNext claims and processes a single job. It reports whether a job was found.
+func (w *Worker) processNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	eval, err := w.repo.ClaimNext(ctx, w.id, w.cfg.LeaseDuration)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) && ctx.Err() == nil {
+			log.Printf("Error claiming next evaluation: %v", err)
+		}
+		return false
+	}
+
+	w.processEvaluation(ctx, eval)
+	return true
+}
+
+// processEvaluation runs the AI evaluation pipeline for a leased job
+func (w *Worker) processEvaluation(ctx context.Context, eval *domain.Evaluation) {
+	log.Printf("Starting AI evaluation for job ID: %s (attempt %d)", eval.ID, eval.Attempts)
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	w.mu.Lock()
+	w.running[eval.ID] = cancel
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		delete(w.running, eval.ID)
+		w.mu.Unlock()
+	}()
+
+	heartbeatDone := make(chan struct{})
+	go w.heartbeat(jobCtx, eval.ID, cancel, heartbeatDone)
+	defer func() {
+		cancel(nil)
+		<-heartbeatDone
+	}()
+
+	// Run the AI pipeline
+	req := ai.EvaluationRequest{
+		CVKey:     eval.CVKey,
+		ReportKey: eval.ReportKey,
+		OnAttempt: func(attempt ai.Attempt) {
+			w.recordAttempt(jobCtx, eval.ID, attempt)
+		},
+	}
+	if eval.JobDescription != nil {
+		req.JobDescription = *eval.JobDescription
+	}
+	if eval.Collection != nil {
+		req.Collection = *eval.Collection
+	}
+
+	output, err := w.aiPipeline.ProcessEvaluation(jobCtx, req)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutting down: hand the job back so it resumes after restart
+			log.Printf("AI pipeline interrupted for evaluation %s, returning it to the queue", eval.ID)
+			w.release(eval.ID)
+			return
+		}
+		if errors.Is(context.Cause(jobCtx), repository.ErrEvaluationCancelled) {
+			log.Printf("Evaluation %s was cancelled, abandoning this run", eval.ID)
+			return
+		}
+		if jobCtx.Err() != nil {
+			log.Printf("Lease lost for evaluation %s, abandoning this run", eval.ID)
+			return
+		}
+
+		log.Printf("AI pipeline failed for evaluation %s: %v", eval.ID, err)
+		w.fail(eval, err)
+		return
+	}
+
+	// Convert result and analysis to JSON
+	resultJSON, err := json.Marshal(output.Result)
+	if err != nil {
+		log.Printf("Error marshaling result for evaluation %s: %v", eval.ID, err)
+		w.fail(eval, err)
+		return
+	}
+
+	analysisJSON, err := json.Marshal(output.Analysis)
+	if err != nil {
+		log.Printf("Error marshaling analysis for evaluation %s: %v", eval.ID, err)
+		w.fail(eval, err)
+		return
+	}
+
+	metadataJSON, err := json.Marshal(evaluationMetadata{Redactions: output.Redactions, FailedPages: output.FailedPages})
+	if err != nil {
+		log.Printf("Error marshaling metadata for evaluation %s: %v", eval.ID, err)
+		w.fail(eval, err)
+		return
+	}
+
+	raw := json.RawMessage(resultJSON)
+	rawAnalysis := json.RawMessage(analysisJSON)
+	rawMetadata := json.RawMessage(metadataJSON)
+	eval.Analysis = &rawAnalysis
+	eval.Metadata = &rawMetadata
+	if w.finish(eval, domain.StatusCompleted, &raw) {
+		log.Printf("Successfully completed AI evaluation for job ID: %s", eval.ID)
+	}
+}
+
+// recordAttempt persists an LLM call attempt on the evaluation row
+func (w *Worker) recordAttempt(ctx context.Context, id uuid.UUID, attempt ai.Attempt) {
+	attemptJSON, err := json.Marshal(attempt)
+	if err != nil {
+		log.Printf("Error marshaling attempt for evaluation %s: %v", id, err)
+		return
+	}
+
+	if err := w.repo.AppendLLMAttempt(ctx, id, attemptJSON); err != nil && ctx.Err() == nil {
+		log.Printf("Error recording attempt for evaluation %s: %v", id, err)
+	}
+}
+
+// heartbeat keeps the lease alive while the job runs and cancels it if the lease is lost
+// or the evaluation was cancelled
+func (w *Worker) heartbeat(ctx context.Context, id uuid.UUID, cancel context.CancelCauseFunc, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(w.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.repo.Heartbeat(ctx, id, w.id, w.cfg.LeaseDuration)
+			if errors.Is(err, repository.ErrLeaseLost) || errors.Is(err, repository.ErrEvaluationCancelled) {
+				cancel(err)
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Error sending heartbeat for evaluation %s: %v", id, err)
+			}
+		}
+	}
+}
+
+// finish stores the final status and releases the lease, unless this worker no longer holds
+// it, and reports whether it was stored. It uses a fresh context so the result is persisted
+// even if the worker is shutting down.
+func (w *Worker) finish(eval *domain.Evaluation, status domain.EvaluationStatus, result *json.RawMessage) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	eval.Status = status
+	eval.Result = result
+	eval.LeaseOwner = nil
+	eval.LeaseExpiresAt = nil
+
+	err := w.repo.Finish(ctx, eval, w.id)
+	switch {
+	case errors.Is(err, repository.ErrEvaluationCancelled):
+		log.Printf("Evaluation %s was cancelled, discarding its %s result", eval.ID, status)
+	case errors.Is(err, repository.ErrLeaseLost):
+		log.Printf("Lease lost for evaluation %s, discarding its %s result", eval.ID, status)
+	case err != nil:
+		log.Printf("Error updating evaluation %s to %s: %v", eval.ID, status, err)
+	}
+	return err == nil
+}
+
+// fail marks the evaluation as failed, recording the stage and category of the error
+func (w *Worker) fail(eval *domain.Evaluation, err error) {
+	code := ai.CodeInternal
+	message := err.Error()
+	eval.FailedStage = nil
+
+	var stageErr *ai.StageError
+	if errors.As(err, &stageErr) {
+		code = stageErr.Code()
+		stage := stageErr.Stage
+		eval.FailedStage = &stage
+		message = stageErr.Err.Error()
+	}
+
+	eval.ErrorCode = &code
+	eval.ErrorMessage = &message
+	w.finish(eval, domain.StatusFailed, nil)
+}
+
+// release returns an interrupted job to the queue
+func (w *Worker) release(id uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := w.repo.Release(ctx, id, w.id); err != nil {
+		log.Printf("Error releasing evaluation %s: %v", id, err)
+	}
+}
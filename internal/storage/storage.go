@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Supported storage backend names
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// ErrNotFound is returned when no object exists under a key
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string // may be empty if the backend does not record it
+	ModTime     time.Time
+}
+
+// Storage stores uploaded files under slash-separated keys such as "3f2a...-cv.pdf"
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+}
+
+// Config selects and configures a storage backend
+type Config struct {
+	Backend string // "local" or "s3"
+
+	// Local backend
+	Root string
+
+	// S3 backend
+	S3 S3Config
+}
+
+// New creates the storage backend selected in cfg
+func New(ctx context.Context, cfg Config) (Storage, error) {
+	switch cfg.Backend {
+	case BackendLocal, "":
+		return NewLocalStorage(cfg.Root)
+	case BackendS3:
+		return NewS3Storage(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// ReadAll returns the content stored under key
+func ReadAll(ctx context.Context, s Storage, key string) ([]byte, error) {
+	r, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// cleanKey rejects keys that are empty or could escape the storage root
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != key || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,171 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Chunk is a piece of a document small enough to embed on its own
+type Chunk struct {
+	Text    string
+	Offset  int    // byte offset of the chunk's own content in the source text
+	Heading string // nearest Markdown heading above the chunk, if any
+}
+
+// Chunker splits text into chunks at headings, then paragraphs, lines and words
+type Chunker struct {
+	size    int
+	overlap int
+}
+
+// separators are tried in order when a section is too large to be one chunk
+var separators = []string{"\n\n", "\n", " "}
+
+// NewChunker creates a chunker producing chunks of at most size bytes, where each chunk after
+// the first in a section repeats up to overlap bytes from the end of the previous one
+func NewChunker(size, overlap int) (*Chunker, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("chunk size must be positive")
+	}
+	if overlap < 0 || overlap >= size {
+		return nil, fmt.Errorf("chunk overlap must be between 0 and the chunk size")
+	}
+	return &Chunker{size: size, overlap: overlap}, nil
+}
+
+// span is a half-open byte range of the source text
+type span struct {
+	start, end int
+}
+
+// Split breaks text into chunks. Chunks never cross a heading, and the same text always
+// yields the same chunks and offsets.
+func (c *Chunker) Split(text string) []Chunk {
+	var chunks []Chunk
+	for _, section := range sections(text) {
+		heading := headingOf(text[section.start:section.end])
+
+		var pieces []span
+		c.splitSpan(text, section, separators, &pieces)
+
+		for i, piece := range c.pack(pieces) {
+			body := strings.TrimSpace(text[piece.start:piece.end])
+			if body == "" || body == heading {
+				continue // a heading with nothing under it carries no guidance
+			}
+
+			chunkText := body
+			if i > 0 {
+				// Continuation chunks repeat the end of the previous chunk and the section heading
+				// so they still make sense on their own
+				chunkText = strings.TrimSpace(c.overlapBefore(text, section.start, piece.start) + body)
+				if heading != "" {
+					chunkText = heading + "\n" + chunkText
+				}
+			}
+
+			chunks = append(chunks, Chunk{Text: chunkText, Offset: piece.start, Heading: heading})
+		}
+	}
+	return chunks
+}
+
+// splitSpan appends pieces of s no larger than the chunk size, splitting on seps in order
+func (c *Chunker) splitSpan(text string, s span, seps []string, pieces *[]span) {
+	if s.end-s.start <= c.size {
+		*pieces = append(*pieces, s)
+		return
+	}
+
+	if len(seps) == 0 {
+		// No separator left; cut at the size limit without splitting a UTF-8 sequence
+		for start := s.start; start < s.end; {
+			end := min(start+c.size, s.end)
+			for end < s.end && end > start+1 && !utf8.RuneStart(text[end]) {
+				end--
+			}
+			*pieces = append(*pieces, span{start, end})
+			start = end
+		}
+		return
+	}
+
+	start := s.start
+	for start < s.end {
+		idx := strings.Index(text[start:s.end], seps[0])
+		end := s.end
+		if idx >= 0 {
+			end = start + idx + len(seps[0])
+		}
+		c.splitSpan(text, span{start, end}, seps[1:], pieces)
+		start = end
+	}
+}
+
+// pack greedily merges adjacent pieces into spans no larger than the chunk size
+func (c *Chunker) pack(pieces []span) []span {
+	var packed []span
+	for _, p := range pieces {
+		if n := len(packed); n > 0 && p.end-packed[n-1].start <= c.size {
+			packed[n-1].end = p.end
+			continue
+		}
+		packed = append(packed, p)
+	}
+	return packed
+}
+
+// overlapBefore returns up to overlap bytes ending at pos, starting on a line or word boundary and not before floor
+func (c *Chunker) overlapBefore(text string, floor, pos int) string {
+	if c.overlap == 0 {
+		return ""
+	}
+
+	start := max(pos-c.overlap, floor)
+	if start > floor {
+		// Prefer starting on a whole line, then on a whole word
+		i := strings.IndexByte(text[start:pos], '\n')
+		if i < 0 {
+			i = strings.IndexByte(text[start:pos], ' ')
+		}
+		if i < 0 {
+			return ""
+		}
+		start += i + 1
+	}
+	return text[start:pos]
+}
+
+// sections splits text at Markdown heading lines
+func sections(text string) []span {
+	var result []span
+	start := 0
+	for offset := 0; offset < len(text); {
+		lineEnd := strings.IndexByte(text[offset:], '\n')
+		next := len(text)
+		if lineEnd >= 0 {
+			next = offset + lineEnd + 1
+		}
+
+		if offset > start && strings.HasPrefix(text[offset:], "#") {
+			result = append(result, span{start, offset})
+			start = offset
+		}
+		offset = next
+	}
+
+	if start < len(text) {
+		result = append(result, span{start, len(text)})
+	}
+	return result
+}
+
+// headingOf returns the first line of a section if it is a Markdown heading
+func headingOf(section string) string {
+	if !strings.HasPrefix(section, "#") {
+		return ""
+	}
+	line, _, _ := strings.Cut(section, "\n")
+	return strings.TrimSpace(line)
+}
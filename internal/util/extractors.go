@@ -0,0 +1,428 @@
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// textBuffer accumulates extracted text, avoiding empty lines where block elements nest
+type textBuffer struct {
+	bytes.Buffer
+}
+
+// endLine finishes the current line, if it has any text
+func (b *textBuffer) endLine() {
+	b.trimTrailing(" \t")
+	if b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteByte('\n')
+	}
+}
+
+// endCell separates a table cell from the next one on the same line
+func (b *textBuffer) endCell() {
+	b.trimTrailing(" \t\n")
+	b.WriteByte('\t')
+}
+
+// writeCollapsed writes HTML text, where any run of whitespace is a single space
+func (b *textBuffer) writeCollapsed(s string) {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		if s != "" {
+			b.space()
+		}
+		return
+	}
+	if strings.TrimLeftFunc(s, unicode.IsSpace) != s {
+		b.space()
+	}
+	b.WriteString(strings.Join(words, " "))
+	if strings.TrimRightFunc(s, unicode.IsSpace) != s {
+		b.space()
+	}
+}
+
+// space writes a space unless the line is empty or already ends with whitespace
+func (b *textBuffer) space() {
+	if b.Len() > 0 && !bytes.ContainsAny(b.Bytes()[b.Len()-1:], " \t\n") {
+		b.WriteByte(' ')
+	}
+}
+
+func (b *textBuffer) trimTrailing(cutset string) {
+	b.Truncate(len(bytes.TrimRight(b.Bytes(), cutset)))
+}
+
+// maxZipEntrySize bounds the uncompressed size of a document part read from an archive, so a
+// small upload cannot inflate to gigabytes
+const maxZipEntrySize = 32 << 20
+
+// maxSpaceRun bounds the spaces an ODT text:s element expands to
+const maxSpaceRun = 100
+
+// readZipEntry returns the content of one file inside a zip archive
+func readZipEntry(data []byte, name string) ([]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	for _, f := range archive.File {
+		if f.Name != name {
+			continue
+		}
+		if f.UncompressedSize64 > maxZipEntrySize {
+			return nil, fmt.Errorf("%s is too large (%d bytes uncompressed)", name, f.UncompressedSize64)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer rc.Close()
+
+		// The recorded size is not trusted; read one byte past the limit to detect a lie
+		content, err := io.ReadAll(io.LimitReader(rc, maxZipEntrySize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if len(content) > maxZipEntrySize {
+			return nil, fmt.Errorf("%s is too large", name)
+		}
+		return content, nil
+	}
+	return nil, fmt.Errorf("failed to open %s: %w", name, fs.ErrNotExist)
+}
+
+// extractDOCX extracts the body text of an OOXML word document
+func extractDOCX(data []byte) (string, error) {
+	document, err := readZipEntry(data, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+
+	var text textBuffer
+	inText := false
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse document.xml: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteByte('\t')
+			case "br", "cr":
+				text.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p", "tr":
+				text.endLine()
+			case "tc":
+				text.endCell()
+			}
+		case xml.CharData:
+			// Only w:t holds document text; field codes and deleted runs use other elements
+			if inText {
+				text.Write(t)
+			}
+		}
+	}
+
+	return normalizeText(text.String()), nil
+}
+
+// extractODT extracts the body text of an OpenDocument text document
+func extractODT(data []byte) (string, error) {
+	content, err := readZipEntry(data, "content.xml")
+	if err != nil {
+		return "", err
+	}
+
+	var text textBuffer
+	depth := 0 // nesting level of text:p and text:h elements
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse content.xml: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p", "h":
+				depth++
+			case "s":
+				// text:s stands for c consecutive spaces
+				count := 1
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "c" {
+						if n, err := strconv.Atoi(attr.Value); err == nil && n > 0 {
+							count = min(n, maxSpaceRun)
+						}
+					}
+				}
+				text.WriteString(strings.Repeat(" ", count))
+			case "tab":
+				text.WriteByte('\t')
+			case "line-break":
+				text.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p", "h":
+				depth--
+				text.endLine()
+			case "table-cell":
+				text.endCell()
+			case "table-row":
+				text.endLine()
+			}
+		case xml.CharData:
+			if depth > 0 {
+				text.Write(t)
+			}
+		}
+	}
+
+	return normalizeText(text.String()), nil
+}
+
+// htmlSkipped elements hold no readable text
+var htmlSkipped = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true, "svg": true,
+}
+
+// htmlBlocks elements start on a new line
+var htmlBlocks = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true, "dd": true,
+	"div": true, "dl": true, "dt": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true, "li": true,
+	"main": true, "nav": true, "ol": true, "p": true, "pre": true, "section": true, "table": true,
+	"tr": true, "ul": true,
+}
+
+// extractHTML extracts the visible text of an HTML document, keeping block structure as lines
+func extractHTML(data []byte) (string, error) {
+	var text textBuffer
+	skipDepth := 0
+	tokenizer := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return "", fmt.Errorf("failed to parse HTML: %w", err)
+			}
+			return normalizeText(text.String()), nil
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			tag := token.Data
+			switch {
+			case htmlSkipped[tag]:
+				if token.Type == html.StartTagToken {
+					skipDepth++
+				}
+			case skipDepth > 0:
+			case tag == "li":
+				text.endLine()
+				text.WriteString("- ")
+			case htmlBlocks[tag]:
+				text.endLine()
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			switch {
+			case htmlSkipped[tag]:
+				if skipDepth > 0 {
+					skipDepth--
+				}
+			case skipDepth > 0:
+			case tag == "td" || tag == "th":
+				text.endCell()
+			case htmlBlocks[tag]:
+				text.endLine()
+			}
+
+		case html.TextToken:
+			if skipDepth == 0 {
+				text.writeCollapsed(string(tokenizer.Text()))
+			}
+		}
+	}
+}
+
+// rtfSkipped destinations hold formatting tables and metadata rather than document text
+var rtfSkipped = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true, "pict": true,
+	"header": true, "headerl": true, "headerr": true, "headerf": true, "footer": true,
+	"footerl": true, "footerr": true, "footerf": true, "listtable": true, "listoverridetable": true,
+	"rsidtbl": true, "generator": true, "xmlnstbl": true, "themedata": true,
+	"colorschememapping": true, "latentstyles": true, "datastore": true, "fldinst": true,
+	"object": true, "filetbl": true, "revtbl": true,
+}
+
+// rtfSymbols maps control words to the text they stand for
+var rtfSymbols = map[string]string{
+	"par": "\n", "line": "\n", "row": "\n", "sect": "\n", "page": "\n",
+	"tab": "\t", "cell": "\t",
+	"emdash": "—", "endash": "–", "bullet": "•",
+	"lquote": "‘", "rquote": "’", "ldblquote": "“", "rdblquote": "”",
+}
+
+// rtfGroup is the state of an RTF group that nested groups inherit
+type rtfGroup struct {
+	skip     bool
+	ucLength int // characters following \u that are fallbacks for older readers
+}
+
+// extractRTF extracts the document text of an RTF file
+func extractRTF(data []byte) (string, error) {
+	var text strings.Builder
+	state := rtfGroup{ucLength: 1}
+	var stack []rtfGroup
+	fallback := 0 // fallback characters still to drop after a \u
+
+	emit := func(s string) {
+		if fallback > 0 {
+			fallback--
+			return
+		}
+		if !state.skip {
+			text.WriteString(s)
+		}
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '{':
+			stack = append(stack, state)
+			fallback = 0
+		case '}':
+			if len(stack) == 0 {
+				return "", fmt.Errorf("failed to parse RTF: unbalanced braces")
+			}
+			state, stack = stack[len(stack)-1], stack[:len(stack)-1]
+			fallback = 0
+		case '\r', '\n':
+			// Raw line breaks are not part of the text
+		case '\\':
+			i++
+			if i >= len(data) {
+				break
+			}
+			switch c = data[i]; {
+			case c == '\\' || c == '{' || c == '}':
+				emit(string(c))
+			case c == '\'':
+				if i+2 < len(data) {
+					if b, err := strconv.ParseUint(string(data[i+1:i+3]), 16, 8); err == nil {
+						emit(string(charmap.Windows1252.DecodeByte(byte(b))))
+					}
+					i += 2
+				}
+			case c == '*':
+				state.skip = true
+			case c == '~':
+				emit(" ")
+			case c == '_':
+				emit("-")
+			case c == '\r' || c == '\n':
+				emit("\n")
+			case isASCIILetter(c):
+				start := i
+				for i < len(data) && isASCIILetter(data[i]) {
+					i++
+				}
+				word := string(data[start:i])
+
+				paramStart := i
+				if i < len(data) && data[i] == '-' {
+					i++
+				}
+				for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+					i++
+				}
+				param, hasParam := 0, i > paramStart
+				if hasParam {
+					param, _ = strconv.Atoi(string(data[paramStart:i]))
+				}
+				// A single space delimits the control word and is not text
+				if i >= len(data) || data[i] != ' ' {
+					i--
+				}
+
+				switch {
+				case rtfSkipped[word]:
+					state.skip = true
+				case word == "uc" && hasParam:
+					state.ucLength = param
+				case word == "u" && hasParam:
+					if param < 0 {
+						param += 65536
+					}
+					emit(string(rune(param)))
+					fallback = state.ucLength
+				case rtfSymbols[word] != "":
+					emit(rtfSymbols[word])
+				}
+			}
+		default:
+			emit(string(charmap.Windows1252.DecodeByte(c)))
+		}
+	}
+
+	return normalizeText(text.String()), nil
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// normalizeText trims trailing spaces from lines and collapses runs of blank lines
+func normalizeText(text string) string {
+	lines := strings.Split(text, "\n")
+	result := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(strings.TrimLeft(line, " "), " \t")
+		if line == "" {
+			if !blank && len(result) > 0 {
+				result = append(result, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		result = append(result, line)
+	}
+	return strings.TrimSpace(strings.Join(result, "\n"))
+}
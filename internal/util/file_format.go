@@ -0,0 +1,124 @@
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnsupportedFormat is returned when a file's content is not one of the supported document formats
+	ErrUnsupportedFormat = errors.New("unsupported file format")
+
+	// ErrEncryptedPDF is returned for password-protected or encrypted PDFs
+	ErrEncryptedPDF = errors.New("PDF is encrypted")
+
+	// ErrTooManyPages is returned for documents with more pages than are extracted
+	ErrTooManyPages = errors.New("document has too many pages")
+)
+
+// Format is a document format recognised by FileReader
+type Format string
+
+const (
+	FormatPDF      Format = "pdf"
+	FormatDOCX     Format = "docx"
+	FormatODT      Format = "odt"
+	FormatRTF      Format = "rtf"
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "markdown"
+	FormatText     Format = "text"
+)
+
+// MIMEType returns the media type files of this format are stored with
+func (f Format) MIMEType() string {
+	switch f {
+	case FormatPDF:
+		return "application/pdf"
+	case FormatDOCX:
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case FormatODT:
+		return odtMimeType
+	case FormatRTF:
+		return "application/rtf"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// odtMimeType is stored uncompressed in the "mimetype" entry of every ODF text document
+const odtMimeType = "application/vnd.oasis.opendocument.text"
+
+// DetectFileFormat reads the file at filePath and detects its format, see DetectFormat
+func DetectFileFormat(filePath string) (Format, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return DetectFormat(data, filepath.Base(filePath))
+}
+
+// DetectFormat identifies the format of data from its content. The file name is only used to
+// tell Markdown from plain text, which cannot be told apart by content.
+func DetectFormat(data []byte, filename string) (Format, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return FormatPDF, nil
+	case bytes.HasPrefix(data, []byte(`{\rtf`)):
+		return FormatRTF, nil
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return detectZipFormat(data)
+	}
+
+	// Everything else must be text; binary files are rejected whatever their extension
+	text := bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(text) || bytes.IndexByte(text, 0) >= 0 {
+		return "", fmt.Errorf("%w: binary content", ErrUnsupportedFormat)
+	}
+
+	if strings.HasPrefix(http.DetectContentType(text), "text/html") {
+		return FormatHTML, nil
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".md", ".markdown":
+		return FormatMarkdown, nil
+	}
+	return FormatText, nil
+}
+
+// detectZipFormat tells OOXML word documents from ODF text documents; other archives are unsupported
+func detectZipFormat(data []byte) (Format, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("%w: corrupt zip archive", ErrUnsupportedFormat)
+	}
+
+	for _, file := range archive.File {
+		switch file.Name {
+		case "word/document.xml":
+			return FormatDOCX, nil
+		case "mimetype":
+			rc, err := file.Open()
+			if err != nil {
+				continue
+			}
+			mimeType, _ := io.ReadAll(io.LimitReader(rc, 128))
+			rc.Close()
+			if strings.TrimSpace(string(mimeType)) == odtMimeType {
+				return FormatODT, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: zip archive is not a DOCX or ODT document", ErrUnsupportedFormat)
+}
@@ -2,12 +2,9 @@ package util
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
-
-	"github.com/ledongthuc/pdf"
 )
 
 // FileReader handles reading and extracting text from various file types
@@ -18,61 +15,78 @@ func NewFileReader() *FileReader {
 	return &FileReader{}
 }
 
-// ReadFile reads and extracts text content from a file based on its extension
-func (fr *FileReader) ReadFile(filePath string) (string, error) {
-	ext := strings.ToLower(filepath.Ext(filePath))
+// Document is the text extracted from a file
+type Document struct {
+	Text   string `json:"text"`
+	Format Format `json:"format"`
 
-	switch ext {
-	case ".pdf":
-		return fr.readPDF(filePath)
-	case ".txt":
-		return fr.readText(filePath)
-	default:
-		return "", fmt.Errorf("unsupported file type: %s", ext)
-	}
+	// Pages and FailedPages are only set for paged formats. FailedPages lists the 1-based
+	// pages whose text could not be extracted and is missing from Text.
+	Pages       int   `json:"pages,omitempty"`
+	FailedPages []int `json:"failed_pages,omitempty"`
 }
 
-// readPDF extracts text content from a PDF file
-func (fr *FileReader) readPDF(filePath string) (string, error) {
-	file, reader, err := pdf.Open(filePath)
+// ReadFile reads and extracts text content from a file, see ReadDocument
+func (fr *FileReader) ReadFile(filePath string) (string, error) {
+	doc, err := fr.ReadDocument(filePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to open PDF file: %w", err)
+		return "", err
 	}
-	defer file.Close()
-
-	var content strings.Builder
-	totalPages := reader.NumPage()
-
-	for i := 1; i <= totalPages; i++ {
-		page := reader.Page(i)
-		if page.V.IsNull() {
-			continue
-		}
+	return doc.Text, nil
+}
 
-		text, err := page.GetPlainText(nil)
-		if err != nil {
-			continue // Skip pages with extraction errors
-		}
+// ReadDocument reads and extracts text content from a file. The format is detected from the
+// content rather than the extension; unsupported files return ErrUnsupportedFormat.
+func (fr *FileReader) ReadDocument(filePath string) (*Document, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return fr.ReadBytes(data, filepath.Base(filePath))
+}
 
-		content.WriteString(text)
-		content.WriteString("\n")
+// CountPages returns the number of pages a document declares without extracting any text,
+// or 0 for formats without pages. It lets callers reject long documents cheaply.
+func (fr *FileReader) CountPages(data []byte, filename string) (int, error) {
+	format, err := DetectFormat(data, filename)
+	if err != nil {
+		return 0, err
+	}
+	if format != FormatPDF {
+		return 0, nil
 	}
 
-	return content.String(), nil
+	_, pages, err := openPDF(data)
+	return pages, err
 }
 
-// readText reads content from a text file
-func (fr *FileReader) readText(filePath string) (string, error) {
-	file, err := os.Open(filePath)
+// ReadBytes extracts text content from file data, e.g. an upload that is not saved yet.
+// The file name is only used to tell Markdown from plain text.
+func (fr *FileReader) ReadBytes(data []byte, filename string) (*Document, error) {
+	format, err := DetectFormat(data, filename)
 	if err != nil {
-		return "", fmt.Errorf("failed to open text file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	content, err := io.ReadAll(file)
+	var text string
+	switch format {
+	case FormatPDF:
+		return fr.readPDF(data)
+	case FormatDOCX:
+		text, err = extractDOCX(data)
+	case FormatODT:
+		text, err = extractODT(data)
+	case FormatRTF:
+		text, err = extractRTF(data)
+	case FormatHTML:
+		text, err = extractHTML(data)
+	default:
+		// Markdown is passed on as is; its headings help the model find sections
+		text = strings.TrimPrefix(string(data), "\ufeff")
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to read text file: %w", err)
+		return nil, err
 	}
 
-	return string(content), nil
+	return &Document{Text: text, Format: format}, nil
 }
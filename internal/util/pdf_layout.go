@@ -0,0 +1,359 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Layout tuning, relative to the font size where it depends on the text
+const (
+	rowTolerance     = 0.5  // glyphs whose baselines differ by less than this share a row
+	wordGapRatio     = 0.2  // a horizontal gap wider than this starts a new word
+	paragraphGap     = 1.8  // a vertical gap wider than this starts a new paragraph
+	gutterBucket     = 2.0  // points per bucket when looking for a column gutter
+	minGutterWidth   = 8.0  // narrowest gap, in points, accepted as a column gutter
+	gutterNoiseRatio = 0.05 // share of rows allowed to cross a gutter, e.g. full-width headings
+	minColumnRows    = 3    // rows each column needs before the page counts as two-column
+)
+
+// glyph is a piece of text placed on the page
+type glyph struct {
+	x, y, w, size float64
+	s             string
+}
+
+// textRow is a line of glyphs sharing a baseline, ordered left to right
+type textRow struct {
+	y      float64
+	size   float64
+	glyphs []glyph
+}
+
+// MaxPDFPages is the most pages extracted from a PDF. A page count is only a number in the
+// file, so without a cap a tiny PDF could keep a reader busy for hours.
+const MaxPDFPages = 1000
+
+// Page tree limits. Trees are walked by hand because the PDF library loops forever on a
+// tree that contains itself.
+const (
+	maxPageTreeDepth = 32
+	maxPageTreeNodes = 4 * MaxPDFPages
+)
+
+// readPDF extracts text from a PDF in reading order. Pages whose content cannot be decoded
+// are reported instead of being skipped silently; the document only fails if no page is readable.
+func (fr *FileReader) readPDF(data []byte) (*Document, error) {
+	reader, pages, err := openPDF(data)
+	if err != nil {
+		return nil, err
+	}
+	if pages > MaxPDFPages {
+		return nil, fmt.Errorf("%w: PDF has %d pages, at most %d are read", ErrTooManyPages, pages, MaxPDFPages)
+	}
+
+	tree, err := pageTree(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Format: FormatPDF, Pages: pages}
+	var texts []string
+	for i := 0; i < doc.Pages && i < len(tree); i++ {
+		text, err := readPDFPage(tree[i])
+		if err != nil {
+			doc.FailedPages = append(doc.FailedPages, i+1)
+			continue
+		}
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	if doc.Pages > 0 && len(doc.FailedPages) == doc.Pages {
+		return nil, fmt.Errorf("failed to extract text from any of the %d PDF pages", doc.Pages)
+	}
+
+	doc.Text = strings.Join(texts, "\n\n")
+	return doc, nil
+}
+
+// openPDF opens a PDF and returns the page count it declares. The PDF library panics on
+// malformed trailers, so panics are turned into errors.
+func openPDF(data []byte) (reader *pdf.Reader, pages int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			reader, pages, err = nil, 0, fmt.Errorf("failed to open PDF file: malformed document: %v", r)
+		}
+	}()
+
+	reader, err = pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if errors.Is(err, pdf.ErrInvalidPassword) {
+		return nil, 0, ErrEncryptedPDF
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open PDF file: %w", err)
+	}
+	// PDFs encrypted with an empty user password open fine but are still rejected, since
+	// their permissions may forbid text extraction
+	if !reader.Trailer().Key("Encrypt").IsNull() {
+		return nil, 0, ErrEncryptedPDF
+	}
+
+	pages = reader.NumPage()
+	if pages < 0 {
+		return nil, 0, fmt.Errorf("failed to open PDF file: invalid page count %d", pages)
+	}
+	return reader, pages, nil
+}
+
+// pageTree returns the pages of a PDF in order, walking at most MaxPDFPages pages
+func pageTree(reader *pdf.Reader) (pages []pdf.Page, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pages, err = nil, fmt.Errorf("failed to read PDF page tree: %v", r)
+		}
+	}()
+
+	nodes := 0
+	var walk func(node pdf.Value, depth int) error
+	walk = func(node pdf.Value, depth int) error {
+		if nodes++; nodes > maxPageTreeNodes || depth > maxPageTreeDepth {
+			return fmt.Errorf("failed to read PDF page tree: too many nested pages")
+		}
+		switch node.Key("Type").Name() {
+		case "Page":
+			if len(pages) >= MaxPDFPages {
+				return fmt.Errorf("%w: PDF has more than %d pages", ErrTooManyPages, MaxPDFPages)
+			}
+			pages = append(pages, pdf.Page{V: node})
+		case "Pages":
+			kids := node.Key("Kids")
+			for i := 0; i < kids.Len(); i++ {
+				if err := walk(kids.Index(i), depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	err = walk(reader.Trailer().Key("Root").Key("Pages"), 0)
+	return pages, err
+}
+
+// readPDFPage lays out the text of one page. The PDF library panics on malformed content
+// streams, so panics are turned into errors.
+func readPDFPage(page pdf.Page) (text string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed page content: %v", r)
+		}
+	}()
+
+	if page.V.IsNull() {
+		return "", nil
+	}
+
+	var glyphs []glyph
+	for _, t := range page.Content().Text {
+		if strings.TrimSpace(strings.ReplaceAll(t.S, "\ufffd", "")) == "" {
+			continue // spacing comes from glyph positions; undecodable glyphs carry no text
+		}
+		g := glyph{x: t.X, y: t.Y, w: t.W, size: math.Max(t.FontSize, 1), s: t.S}
+		if g.w <= 0 {
+			// Fonts without width metrics; assume an average glyph is half an em wide
+			g.w = 0.5 * g.size * float64(utf8.RuneCountInString(t.S))
+		}
+		glyphs = append(glyphs, g)
+	}
+	return layoutText(glyphs), nil
+}
+
+// layoutText orders glyphs into lines. On two-column pages the left column is read before the
+// right one, with rows spanning both columns (such as a name banner) kept in place.
+func layoutText(glyphs []glyph) string {
+	rows := groupRows(glyphs)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	gutterStart, gutterEnd, ok := findGutter(rows)
+	if !ok {
+		return joinRows(rows)
+	}
+
+	var out, left, right []textRow
+	flush := func() {
+		out = append(out, left...)
+		out = append(out, right...)
+		left, right = nil, nil
+	}
+	for _, row := range rows {
+		var l, r []glyph
+		crosses := false
+		for _, g := range row.glyphs {
+			switch {
+			case g.x+g.w <= gutterStart+0.5:
+				l = append(l, g)
+			case g.x >= gutterEnd-0.5:
+				r = append(r, g)
+			default:
+				crosses = true
+			}
+		}
+
+		if crosses {
+			flush()
+			out = append(out, row)
+			continue
+		}
+		if len(l) > 0 {
+			left = append(left, textRow{y: row.y, size: row.size, glyphs: l})
+		}
+		if len(r) > 0 {
+			right = append(right, textRow{y: row.y, size: row.size, glyphs: r})
+		}
+	}
+	flush()
+
+	return joinRows(out)
+}
+
+// groupRows sorts glyphs top to bottom and merges those on the same baseline into rows
+func groupRows(glyphs []glyph) []textRow {
+	sort.SliceStable(glyphs, func(i, j int) bool {
+		return glyphs[i].y > glyphs[j].y
+	})
+
+	var rows []textRow
+	for _, g := range glyphs {
+		if n := len(rows); n > 0 && math.Abs(rows[n-1].y-g.y) <= rowTolerance*math.Max(rows[n-1].size, g.size) {
+			rows[n-1].glyphs = append(rows[n-1].glyphs, g)
+			rows[n-1].size = math.Max(rows[n-1].size, g.size)
+			continue
+		}
+		rows = append(rows, textRow{y: g.y, size: g.size, glyphs: []glyph{g}})
+	}
+
+	for _, row := range rows {
+		sort.SliceStable(row.glyphs, func(i, j int) bool {
+			return row.glyphs[i].x < row.glyphs[j].x
+		})
+	}
+	return rows
+}
+
+// findGutter looks for a vertical strip in the middle half of the text that almost no row
+// crosses, with enough text on both sides to be two columns
+func findGutter(rows []textRow) (start, end float64, ok bool) {
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	for _, row := range rows {
+		for _, g := range row.glyphs {
+			minX = math.Min(minX, g.x)
+			maxX = math.Max(maxX, g.x+g.w)
+		}
+	}
+	width := maxX - minX
+	if width < 4*minGutterWidth {
+		return 0, 0, false
+	}
+
+	lo, hi := minX+width/4, minX+3*width/4
+	buckets := make([]int, int((hi-lo)/gutterBucket)+1)
+	for _, row := range rows {
+		covered := make([]bool, len(buckets))
+		for _, g := range row.glyphs {
+			first := max(int((g.x-lo)/gutterBucket), 0)
+			last := min(int((g.x+g.w-lo)/gutterBucket), len(buckets)-1)
+			for b := first; b <= last; b++ {
+				covered[b] = true
+			}
+		}
+		for b, c := range covered {
+			if c {
+				buckets[b]++
+			}
+		}
+	}
+
+	// The widest run of buckets that few rows cross is the gutter candidate
+	noise := int(float64(len(rows)) * gutterNoiseRatio)
+	bestStart, bestLen := 0, 0
+	for b := 0; b < len(buckets); {
+		if buckets[b] > noise {
+			b++
+			continue
+		}
+		runStart := b
+		for b < len(buckets) && buckets[b] <= noise {
+			b++
+		}
+		if b-runStart > bestLen {
+			bestStart, bestLen = runStart, b-runStart
+		}
+	}
+	if float64(bestLen)*gutterBucket < minGutterWidth {
+		return 0, 0, false
+	}
+
+	start = lo + float64(bestStart)*gutterBucket
+	end = start + float64(bestLen)*gutterBucket
+
+	leftRows, rightRows := 0, 0
+	for _, row := range rows {
+		hasLeft, hasRight := false, false
+		for _, g := range row.glyphs {
+			hasLeft = hasLeft || g.x+g.w <= start
+			hasRight = hasRight || g.x >= end
+		}
+		if hasLeft {
+			leftRows++
+		}
+		if hasRight {
+			rightRows++
+		}
+	}
+	if leftRows < minColumnRows || rightRows < minColumnRows {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// joinRows renders rows as lines, with a blank line where the vertical gap suggests a new paragraph
+func joinRows(rows []textRow) string {
+	var text strings.Builder
+	for i, row := range rows {
+		if i > 0 {
+			text.WriteByte('\n')
+			if gap := rows[i-1].y - row.y; gap > paragraphGap*row.size || gap < 0 {
+				text.WriteByte('\n') // a larger gap, or a jump back up to the next column
+			}
+		}
+		text.WriteString(row.text())
+	}
+	return normalizeText(text.String())
+}
+
+// text joins the glyphs of a row, adding spaces where the gap between glyphs separates words
+func (r textRow) text() string {
+	var text strings.Builder
+	end := math.Inf(-1)
+	for _, g := range r.glyphs {
+		if text.Len() > 0 && g.x-end > wordGapRatio*g.size {
+			s := text.String()
+			if !strings.HasSuffix(s, " ") && !strings.HasPrefix(g.s, " ") {
+				text.WriteByte(' ')
+			}
+		}
+		text.WriteString(g.s)
+		end = math.Max(end, g.x+g.w)
+	}
+	return strings.TrimSpace(text.String())
+}
@@ -0,0 +1,236 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fontSize is used for every glyph in the fixtures; each character is half an em wide
+const fontSize = 10.0
+
+// words places a line of words starting at x on baseline y, one word per glyph with a
+// single character's width between them
+func words(x, y float64, text string) []glyph {
+	var glyphs []glyph
+	for _, w := range strings.Fields(text) {
+		width := 0.5 * fontSize * float64(len(w))
+		glyphs = append(glyphs, glyph{x: x, y: y, w: width, size: fontSize, s: w})
+		x += width + 0.5*fontSize
+	}
+	return glyphs
+}
+
+// twoColumnPage returns a page with n rows in a left column at x=50 and a right column at
+// x=330, sharing baselines, and the lines expected from each column
+func twoColumnPage(n int) (glyphs []glyph, left, right []string) {
+	for i := 0; i < n; i++ {
+		y := 700 - float64(i)*14
+		l := fmt.Sprintf("Left line %d about Go", i)
+		r := fmt.Sprintf("Right line %d with skills", i)
+		glyphs = append(glyphs, words(50, y, l)...)
+		glyphs = append(glyphs, words(330, y, r)...)
+		left = append(left, l)
+		right = append(right, r)
+	}
+	return glyphs, left, right
+}
+
+func TestLayoutTextSingleColumn(t *testing.T) {
+	lines := []string{
+		"Jane Doe",
+		"Backend engineer with seven years of experience building distributed systems",
+		"Designed and operated payment services handling millions of requests per day",
+		"Led the migration from a monolith to services written in Go and deployed on Kubernetes",
+		"Mentored four engineers and introduced structured code reviews across the team",
+	}
+	var glyphs []glyph
+	for i, line := range lines {
+		glyphs = append(glyphs, words(50, 700-float64(i)*14, line)...)
+	}
+
+	if _, _, ok := findGutter(groupRows(glyphs)); ok {
+		t.Fatal("findGutter found a gutter on a single-column page")
+	}
+	if got, want := layoutText(glyphs), strings.Join(lines, "\n"); got != want {
+		t.Errorf("layoutText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLayoutTextTwoColumns(t *testing.T) {
+	glyphs, left, right := twoColumnPage(6)
+
+	start, end, ok := findGutter(groupRows(glyphs))
+	if !ok {
+		t.Fatal("findGutter found no gutter on a two-column page")
+	}
+	leftEdge := 50 + 0.5*fontSize*float64(len("Left line 0 about Go"))
+	if start < leftEdge || end > 330 {
+		t.Errorf("gutter = [%.1f, %.1f], want it within [%.1f, 330]", start, end, leftEdge)
+	}
+
+	// The left column is read first; the jump back up separates it from the right column
+	want := strings.Join(left, "\n") + "\n\n" + strings.Join(right, "\n")
+	if got := layoutText(glyphs); got != want {
+		t.Errorf("layoutText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLayoutTextFullWidthBanner(t *testing.T) {
+	// Enough rows for a single full-width row to stay under the gutter noise threshold
+	glyphs, left, right := twoColumnPage(20)
+	banner := "Jane Doe Senior Backend Engineer jane at example dot com Jakarta Indonesia"
+	glyphs = append(words(50, 750, banner), glyphs...)
+
+	if _, _, ok := findGutter(groupRows(glyphs)); !ok {
+		t.Fatal("findGutter found no gutter on a two-column page with a banner")
+	}
+
+	want := banner + "\n\n" + strings.Join(left, "\n") + "\n\n" + strings.Join(right, "\n")
+	if got := layoutText(glyphs); got != want {
+		t.Errorf("layoutText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLayoutTextTooFewColumnRows(t *testing.T) {
+	// A short right-hand note beside a column is not a second column
+	glyphs, left, _ := twoColumnPage(5)
+	var leftOnly []glyph
+	for _, g := range glyphs {
+		if g.x < 300 {
+			leftOnly = append(leftOnly, g)
+		}
+	}
+	leftOnly = append(leftOnly, words(330, 700, "2019 - 2023")...)
+
+	if _, _, ok := findGutter(groupRows(leftOnly)); ok {
+		t.Fatal("findGutter accepted a column with a single row")
+	}
+	want := left[0] + " 2019 - 2023\n" + strings.Join(left[1:], "\n")
+	if got := layoutText(leftOnly); got != want {
+		t.Errorf("layoutText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGroupRows(t *testing.T) {
+	glyphs := []glyph{
+		{x: 120, y: 699.8, w: 20, size: fontSize, s: "world"},
+		{x: 50, y: 600, w: 20, size: fontSize, s: "below"},
+		{x: 50, y: 700, w: 20, size: fontSize, s: "hello"},
+	}
+
+	rows := groupRows(glyphs)
+	if len(rows) != 2 {
+		t.Fatalf("groupRows() returned %d rows, want 2", len(rows))
+	}
+	if got := rows[0].text(); got != "hello world" {
+		t.Errorf("first row = %q, want %q", got, "hello world")
+	}
+	if got := rows[1].text(); got != "below" {
+		t.Errorf("second row = %q, want %q", got, "below")
+	}
+}
+
+func TestJoinRowsParagraphs(t *testing.T) {
+	glyphs := append(words(50, 700, "Experience"), words(50, 686, "Engineer at Acme")...)
+	glyphs = append(glyphs, words(50, 640, "Education")...)
+
+	want := "Experience\nEngineer at Acme\n\nEducation"
+	if got := layoutText(glyphs); got != want {
+		t.Errorf("layoutText() = %q, want %q", got, want)
+	}
+}
+
+// buildPDF assembles numbered objects into a PDF with a valid cross-reference table
+func buildPDF(objects ...string) []byte {
+	var b strings.Builder
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return []byte(b.String())
+}
+
+// onePagePDF returns a PDF with a single page of text in a font with fixed-width metrics
+func onePagePDF() []byte {
+	widths := strings.TrimSpace(strings.Repeat("600 ", 95))
+	content := "BT /F1 12 Tf 72 720 Td (Jane Doe, backend engineer) Tj ET"
+	return buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /FirstChar 32 /LastChar 126 /Widths ["+widths+"] >>",
+	)
+}
+
+func TestReadPDF(t *testing.T) {
+	doc, err := NewFileReader().ReadBytes(onePagePDF(), "cv.pdf")
+	if err != nil {
+		t.Fatalf("ReadBytes() error = %v", err)
+	}
+	if doc.Format != FormatPDF || doc.Pages != 1 || !strings.Contains(doc.Text, "backend engineer") {
+		t.Errorf("ReadBytes() = %+v", doc)
+	}
+}
+
+func TestReadPDFPageCap(t *testing.T) {
+	// A tiny PDF may claim any number of pages; none are read past the cap
+	data := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [9 0 R] /Count 20000000 >>",
+	)
+	start := time.Now()
+	_, err := NewFileReader().ReadBytes(data, "cv.pdf")
+	if !errors.Is(err, ErrTooManyPages) {
+		t.Errorf("ReadBytes() error = %v, want ErrTooManyPages", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ReadBytes() took %s", elapsed)
+	}
+}
+
+func TestReadPDFMalformed(t *testing.T) {
+	valid := onePagePDF()
+	reader := NewFileReader()
+
+	// Truncated and corrupted files must fail with an error rather than a panic or a hang
+	var inputs [][]byte
+	for n := 8; n < len(valid); n += 7 {
+		inputs = append(inputs, valid[:n])
+	}
+	for i := 9; i < len(valid); i += 5 {
+		corrupt := append([]byte(nil), valid...)
+		corrupt[i] ^= 0x5a
+		inputs = append(inputs, corrupt)
+	}
+	inputs = append(inputs,
+		buildPDF("<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids 3 0 R /Count 2 >>", "(not a page)"),
+		// Page trees containing themselves
+		buildPDF("<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [2 0 R] /Count 1 >>"),
+		buildPDF("<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [2 0 R 2 0 R 2 0 R] /Count 3 >>"),
+		buildPDF("<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Count -1 >>"),
+		buildPDF("(not a catalog)"),
+	)
+
+	for i, data := range inputs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("input %d: ReadBytes() panicked: %v", i, r)
+				}
+			}()
+			reader.ReadBytes(data, "cv.pdf")
+		}()
+	}
+}
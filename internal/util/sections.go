@@ -0,0 +1,150 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Section names returned by DetectSections
+const (
+	SectionHeader         = "header" // text above the first heading, usually name and contact details
+	SectionSummary        = "summary"
+	SectionExperience     = "experience"
+	SectionEducation      = "education"
+	SectionSkills         = "skills"
+	SectionProjects       = "projects"
+	SectionCertifications = "certifications"
+	SectionOther          = "other" // recognised headings outside the sections above, e.g. Interests
+)
+
+// maxHeadingLength is the longest line, in characters, considered as a section heading
+const maxHeadingLength = 40
+
+// Section is a part of a CV under one heading
+type Section struct {
+	Name    string `json:"name"`
+	Heading string `json:"heading,omitempty"` // the heading as written in the document
+	Content string `json:"content"`
+}
+
+// sectionHeadings maps normalised CV headings to section names
+var sectionHeadings = map[string]string{
+	"summary":                     SectionSummary,
+	"professional summary":        SectionSummary,
+	"profile":                     SectionSummary,
+	"professional profile":        SectionSummary,
+	"about":                       SectionSummary,
+	"about me":                    SectionSummary,
+	"objective":                   SectionSummary,
+	"career objective":            SectionSummary,
+	"experience":                  SectionExperience,
+	"work experience":             SectionExperience,
+	"professional experience":     SectionExperience,
+	"relevant experience":         SectionExperience,
+	"employment":                  SectionExperience,
+	"employment history":          SectionExperience,
+	"work history":                SectionExperience,
+	"career history":              SectionExperience,
+	"education":                   SectionEducation,
+	"education and training":      SectionEducation,
+	"academic background":         SectionEducation,
+	"academic qualifications":     SectionEducation,
+	"skills":                      SectionSkills,
+	"technical skills":            SectionSkills,
+	"key skills":                  SectionSkills,
+	"core skills":                 SectionSkills,
+	"skills and tools":            SectionSkills,
+	"skills and technologies":     SectionSkills,
+	"core competencies":           SectionSkills,
+	"competencies":                SectionSkills,
+	"tech stack":                  SectionSkills,
+	"technologies":                SectionSkills,
+	"projects":                    SectionProjects,
+	"personal projects":           SectionProjects,
+	"selected projects":           SectionProjects,
+	"side projects":               SectionProjects,
+	"key projects":                SectionProjects,
+	"open source":                 SectionProjects,
+	"certifications":              SectionCertifications,
+	"certificates":                SectionCertifications,
+	"licenses and certifications": SectionCertifications,
+	"courses":                     SectionCertifications,
+	"awards":                      SectionOther,
+	"achievements":                SectionOther,
+	"publications":                SectionOther,
+	"languages":                   SectionOther,
+	"interests":                   SectionOther,
+	"hobbies":                     SectionOther,
+	"volunteering":                SectionOther,
+	"volunteer experience":        SectionOther,
+	"references":                  SectionOther,
+}
+
+// DetectSections splits CV text at recognised headings such as "Work Experience" or
+// "SKILLS:". Text above the first heading becomes the header section. It returns nil if no
+// heading is recognised, in which case the text should be used as a whole.
+func DetectSections(text string) []Section {
+	var sections []Section
+	current := Section{Name: SectionHeader}
+	var content []string
+	found := false
+
+	flush := func() {
+		current.Content = strings.TrimSpace(strings.Join(content, "\n"))
+		if current.Content != "" {
+			sections = append(sections, current)
+		}
+		content = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		name, heading, rest, ok := parseHeading(line)
+		if !ok {
+			content = append(content, line)
+			continue
+		}
+
+		found = true
+		flush()
+		current = Section{Name: name, Heading: heading}
+		if rest != "" {
+			content = append(content, rest)
+		}
+	}
+	flush()
+
+	if !found {
+		return nil
+	}
+	return sections
+}
+
+// parseHeading recognises a heading line. A heading may be followed by its content on the
+// same line after a colon, as in "Skills: Go, PostgreSQL".
+func parseHeading(line string) (name, heading, rest string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", "", "", false
+	}
+
+	candidate := line
+	if before, after, found := strings.Cut(line, ":"); found {
+		candidate, rest = before, strings.Trim(after, "*_ \t")
+	}
+	if utf8.RuneCountInString(candidate) > maxHeadingLength {
+		return "", "", "", false
+	}
+
+	name, ok = sectionHeadings[normalizeHeading(candidate)]
+	if !ok {
+		return "", "", "", false
+	}
+	return name, strings.Trim(strings.TrimSpace(candidate), "#*_ "), rest, true
+}
+
+// normalizeHeading lowercases a heading and strips Markdown markers and punctuation
+func normalizeHeading(s string) string {
+	s = strings.ToLower(strings.Trim(strings.TrimSpace(s), "#*_=-:. "))
+	s = strings.ReplaceAll(s, "&", " and ")
+	return strings.Join(strings.Fields(s), " ")
+}
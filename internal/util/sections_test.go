@@ -0,0 +1,82 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectSections(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Section
+	}{
+		{
+			name: "no headings",
+			text: "Jane Doe\nBackend engineer who likes Go",
+			want: nil,
+		},
+		{
+			name: "plain and uppercase headings",
+			text: "Jane Doe\njane@example.com\n\nWork Experience\nEngineer at Acme\n\nEDUCATION\nBSc Computer Science",
+			want: []Section{
+				{Name: SectionHeader, Content: "Jane Doe\njane@example.com"},
+				{Name: SectionExperience, Heading: "Work Experience", Content: "Engineer at Acme"},
+				{Name: SectionEducation, Heading: "EDUCATION", Content: "BSc Computer Science"},
+			},
+		},
+		{
+			name: "markdown headings",
+			text: "# Jane Doe\n\n## Professional Summary\nBuilds APIs\n\n**Skills & Tools**\n- Go\n- PostgreSQL",
+			want: []Section{
+				{Name: SectionHeader, Content: "# Jane Doe"},
+				{Name: SectionSummary, Heading: "Professional Summary", Content: "Builds APIs"},
+				{Name: SectionSkills, Heading: "Skills & Tools", Content: "- Go\n- PostgreSQL"},
+			},
+		},
+		{
+			name: "content on the heading line",
+			text: "Skills: Go, PostgreSQL\nProjects: CV evaluator",
+			want: []Section{
+				{Name: SectionSkills, Heading: "Skills", Content: "Go, PostgreSQL"},
+				{Name: SectionProjects, Heading: "Projects", Content: "CV evaluator"},
+			},
+		},
+		{
+			name: "other sections and empty sections",
+			text: "Summary\n\nInterests\nClimbing\nCertifications:",
+			want: []Section{
+				{Name: SectionOther, Heading: "Interests", Content: "Climbing"},
+			},
+		},
+		{
+			name: "sentences mentioning headings are content",
+			text: "Experience\nMy experience with skills in Go\nSkills gained at Acme include leading a team of engineers",
+			want: []Section{
+				{Name: SectionExperience, Heading: "Experience", Content: "My experience with skills in Go\nSkills gained at Acme include leading a team of engineers"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectSections(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DetectSections() =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeHeading(t *testing.T) {
+	tests := map[string]string{
+		"  WORK EXPERIENCE:  ": "work experience",
+		"## Skills & Tools":    "skills and tools",
+		"**Education**":        "education",
+		"=== Projects ===":     "projects",
+	}
+	for in, want := range tests {
+		if got := normalizeHeading(in); got != want {
+			t.Errorf("normalizeHeading(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package vectorstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"aicvevaluator/internal/chromadb"
+	"aicvevaluator/internal/util"
+)
+
+// EmbeddedConfig describes where an EmbeddedStore loads its guidelines from
+type EmbeddedConfig struct {
+	// Dir holds the guideline files of the default collection. Each subdirectory is loaded
+	// as a separate collection named after it, e.g. Dir/guidelines_backend.
+	Dir string
+
+	// Collection names the default collection, so queries naming it explicitly also match
+	Collection string
+
+	// CachePath, if set, is a file where embeddings are kept between restarts so unchanged
+	// chunks are not embedded again; otherwise the store lives only in memory
+	CachePath string
+
+	ChunkSize    int
+	ChunkOverlap int
+}
+
+// EmbeddedStore is an in-process vector store over guideline files. It chunks files the same
+// way the seeder does and ranks chunks by cosine similarity, so retrieval behaves like
+// ChromaDB without a server, e.g. in tests, air-gapped deployments or while ChromaDB is down.
+type EmbeddedStore struct {
+	embedder    chromadb.Embedder
+	name        string
+	collections map[string][]entry // "" is the default collection
+}
+
+// entry is a stored chunk and its embedding
+type entry struct {
+	doc    chromadb.Document
+	vector []float64
+}
+
+// embeddingCache is the on-disk format of EmbeddedConfig.CachePath
+type embeddingCache struct {
+	Embedder string               `json:"embedder"`
+	Vectors  map[string][]float64 `json:"vectors"` // keyed by SHA-256 of the chunk text
+}
+
+// NewEmbeddedStore loads and embeds every guideline file under cfg.Dir
+func NewEmbeddedStore(ctx context.Context, embedder chromadb.Embedder, cfg EmbeddedConfig) (*EmbeddedStore, error) {
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize, cfg.ChunkOverlap = DefaultChunkSize, DefaultChunkOverlap
+	}
+	chunker, err := util.NewChunker(cfg.ChunkSize, cfg.ChunkOverlap)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := loadCache(cfg.CachePath, embedder.Identity())
+	cachedBefore := len(cache.Vectors)
+
+	store := &EmbeddedStore{
+		embedder:    embedder,
+		name:        cfg.Collection,
+		collections: make(map[string][]entry),
+	}
+
+	entries, err := loadCollection(ctx, embedder, chunker, cfg, cfg.Dir, cache)
+	if err != nil {
+		return nil, err
+	}
+	store.collections[""] = entries
+
+	dirEntries, err := os.ReadDir(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read guidelines directory: %w", err)
+	}
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		entries, err := loadCollection(ctx, embedder, chunker, cfg, filepath.Join(cfg.Dir, dirEntry.Name()), cache)
+		if err != nil {
+			return nil, err
+		}
+		store.collections[dirEntry.Name()] = entries
+	}
+
+	if cfg.CachePath != "" {
+		changed := len(cache.Vectors) != cachedBefore
+		if store.pruneCache(cache) {
+			changed = true
+		}
+		if changed {
+			if err := saveCache(cfg.CachePath, cache); err != nil {
+				log.Printf("Warning: failed to save embedding cache: %v", err)
+			}
+		}
+	}
+
+	return store, nil
+}
+
+// loadCollection chunks and embeds the guideline files directly inside dir
+func loadCollection(ctx context.Context, embedder chromadb.Embedder, chunker *util.Chunker, cfg EmbeddedConfig, dir string, cache *embeddingCache) ([]entry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read guidelines directory: %w", err)
+	}
+
+	var docs []chromadb.Document
+	for _, file := range files {
+		if file.IsDir() || !IsGuidelineFile(file.Name()) {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read guideline file: %w", err)
+		}
+		hash := ContentHash(content, cfg.ChunkSize, cfg.ChunkOverlap)
+		fileDocs, err := GuidelineDocuments(file.Name(), content, chunker, hash)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, fileDocs...)
+	}
+
+	// Embed only chunks that are not in the cache
+	var missing []string
+	for _, doc := range docs {
+		if _, ok := cache.Vectors[textKey(doc.Content)]; !ok {
+			missing = append(missing, doc.Content)
+		}
+	}
+	if len(missing) > 0 {
+		vectors, err := embedder.EmbedDocuments(ctx, missing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed guidelines: %w", err)
+		}
+		for i, text := range missing {
+			cache.Vectors[textKey(text)] = vectors[i]
+		}
+	}
+
+	entries := make([]entry, len(docs))
+	for i, doc := range docs {
+		entries[i] = entry{doc: doc, vector: cache.Vectors[textKey(doc.Content)]}
+	}
+	return entries, nil
+}
+
+// Query returns the chunks most similar to opts.QueryText, closest first. Distances are
+// squared L2 distances between the normalised vectors, i.e. 2 * (1 - cosine similarity),
+// which matches ChromaDB's default "l2" space for normalised embeddings.
+func (s *EmbeddedStore) Query(ctx context.Context, opts chromadb.QueryOptions) ([]chromadb.Document, error) {
+	if opts.NResults <= 0 {
+		return nil, fmt.Errorf("NResults must be positive")
+	}
+
+	collection := opts.Collection
+	if collection == s.name {
+		collection = ""
+	}
+	entries, ok := s.collections[collection]
+	if !ok {
+		return nil, chromadb.ErrCollectionNotFound
+	}
+
+	query, err := s.embedder.EmbedQuery(ctx, opts.QueryText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	var matches []chromadb.Document
+	for _, e := range entries {
+		ok, err := matchWhere(e.doc.Metadata, opts.Where)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if ok, err = matchWhereDocument(e.doc.Content, opts.WhereDocument); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+
+		doc := e.doc
+		doc.Distance = 2 * (1 - cosineSimilarity(query, e.vector))
+		if opts.MaxDistance > 0 && doc.Distance > opts.MaxDistance {
+			continue
+		}
+		matches = append(matches, doc)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Distance < matches[j].Distance
+	})
+	if len(matches) > opts.NResults {
+		matches = matches[:opts.NResults]
+	}
+
+	// Honour Include like ChromaDB does, so callers see the same fields from both stores
+	if len(opts.Include) > 0 {
+		for i := range matches {
+			if !slices.Contains(opts.Include, chromadb.IncludeDocuments) {
+				matches[i].Content = ""
+			}
+			if !slices.Contains(opts.Include, chromadb.IncludeMetadatas) {
+				matches[i].Metadata = nil
+			}
+			if !slices.Contains(opts.Include, chromadb.IncludeDistances) {
+				matches[i].Distance = 0
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// pruneCache drops cached embeddings of chunks that no longer exist and reports whether any were dropped
+func (s *EmbeddedStore) pruneCache(cache *embeddingCache) bool {
+	used := make(map[string]bool)
+	for _, entries := range s.collections {
+		for _, e := range entries {
+			used[textKey(e.doc.Content)] = true
+		}
+	}
+
+	pruned := false
+	for key := range cache.Vectors {
+		if !used[key] {
+			delete(cache.Vectors, key)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+// Count returns the number of chunks in the default collection
+func (s *EmbeddedStore) Count() int {
+	return len(s.collections[""])
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func textKey(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadCache reads the embedding cache, starting empty if it is missing or was built by another embedder
+func loadCache(path, identity string) *embeddingCache {
+	empty := &embeddingCache{Embedder: identity, Vectors: make(map[string][]float64)}
+	if path == "" {
+		return empty
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return empty
+	}
+	if err != nil {
+		log.Printf("Warning: failed to read embedding cache, re-embedding guidelines: %v", err)
+		return empty
+	}
+
+	var cache embeddingCache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.Embedder != identity || cache.Vectors == nil {
+		log.Printf("Embedding cache %s is stale or unreadable, re-embedding guidelines", path)
+		return empty
+	}
+	return &cache
+}
+
+// saveCache writes the cache atomically so a crash never leaves a truncated file
+func saveCache(path string, cache *embeddingCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,307 @@
+package vectorstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"aicvevaluator/internal/chromadb"
+)
+
+// countingEmbedder records how many documents were embedded
+type countingEmbedder struct {
+	chromadb.Embedder
+	embedded int
+}
+
+func (e *countingEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float64, error) {
+	e.embedded += len(texts)
+	return e.Embedder.EmbedDocuments(ctx, texts)
+}
+
+// writeGuidelines creates a guidelines directory with a default and a backend collection
+func writeGuidelines(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"cv_evaluation.txt":                     "CV evaluation. Score backend skills such as Go, PostgreSQL and Kubernetes.",
+		"project_evaluation.txt":                "Project evaluation criteria: correctness, error handling, retries and documentation.",
+		"README.md":                             "Not a guideline file.",
+		"guidelines_backend/cv_evaluation.txt":  "Backend track: weigh distributed systems, queues and database design.",
+		"guidelines_backend/project_extras.txt": "---\ncategory: evaluation_criteria\n---\nBackend projects should include load tests.",
+	}
+	for name, content := range files {
+		writeFile(t, filepath.Join(dir, name), content)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestStore(t *testing.T, dir string, embedder chromadb.Embedder, cachePath string) *EmbeddedStore {
+	t.Helper()
+	store, err := NewEmbeddedStore(context.Background(), embedder, EmbeddedConfig{
+		Dir:        dir,
+		Collection: "evaluation_guidelines",
+		CachePath:  cachePath,
+	})
+	if err != nil {
+		t.Fatalf("NewEmbeddedStore() error = %v", err)
+	}
+	return store
+}
+
+func TestEmbeddedStoreQuery(t *testing.T) {
+	store := newTestStore(t, writeGuidelines(t), chromadb.NewHashingEmbedder(0), "")
+	ctx := context.Background()
+
+	if got := store.Count(); got != 2 {
+		t.Fatalf("Count() = %d, want 2", got)
+	}
+
+	docs, err := store.Query(ctx, chromadb.QueryOptions{QueryText: "error handling and retries", NResults: 5})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("Query() returned %d documents, want 2", len(docs))
+	}
+	if docs[0].ID != "project_evaluation:0" {
+		t.Errorf("closest document = %s, want project_evaluation:0", docs[0].ID)
+	}
+	if docs[0].Distance > docs[1].Distance {
+		t.Errorf("documents are not ordered by distance: %v, %v", docs[0].Distance, docs[1].Distance)
+	}
+	if docs[0].Metadata["category"] != "evaluation_criteria" || docs[0].Content == "" {
+		t.Errorf("closest document = %+v, want its content and metadata", docs[0])
+	}
+
+	// NResults caps the matches, and the default collection can be named explicitly
+	docs, err = store.Query(ctx, chromadb.QueryOptions{QueryText: "Go", NResults: 1, Collection: "evaluation_guidelines"})
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("Query() = %d documents, %v; want 1", len(docs), err)
+	}
+
+	if _, err := store.Query(ctx, chromadb.QueryOptions{QueryText: "Go"}); err == nil {
+		t.Error("Query() accepted NResults 0")
+	}
+}
+
+func TestEmbeddedStoreCollections(t *testing.T) {
+	store := newTestStore(t, writeGuidelines(t), chromadb.NewHashingEmbedder(0), "")
+	ctx := context.Background()
+
+	docs, err := store.Query(ctx, chromadb.QueryOptions{QueryText: "queues", NResults: 5, Collection: "guidelines_backend"})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(docs) != 2 || docs[0].ID != "cv_evaluation:0" || docs[0].Metadata["source"] != "cv_evaluation.txt" {
+		t.Errorf("Query() = %+v, want both backend guidelines, the CV guideline first", docs)
+	}
+
+	_, err = store.Query(ctx, chromadb.QueryOptions{QueryText: "queues", NResults: 5, Collection: "guidelines_frontend"})
+	if !errors.Is(err, chromadb.ErrCollectionNotFound) {
+		t.Errorf("Query() on a missing collection error = %v, want ErrCollectionNotFound", err)
+	}
+}
+
+func TestEmbeddedStoreFilters(t *testing.T) {
+	store := newTestStore(t, writeGuidelines(t), chromadb.NewHashingEmbedder(0), "")
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		opts chromadb.QueryOptions
+		want []string
+	}{
+		{
+			name: "where",
+			opts: chromadb.QueryOptions{Where: map[string]interface{}{"category": "cv_evaluation"}},
+			want: []string{"cv_evaluation:0"},
+		},
+		{
+			name: "where $in",
+			opts: chromadb.QueryOptions{Where: map[string]interface{}{
+				"category": map[string]interface{}{"$in": []string{"cv_evaluation", "evaluation_criteria"}},
+			}},
+			want: []string{"project_evaluation:0", "cv_evaluation:0"},
+		},
+		{
+			name: "where_document",
+			opts: chromadb.QueryOptions{WhereDocument: map[string]interface{}{"$contains": "Kubernetes"}},
+			want: []string{"cv_evaluation:0"},
+		},
+		{
+			name: "where and where_document",
+			opts: chromadb.QueryOptions{
+				Where:         map[string]interface{}{"category": "cv_evaluation"},
+				WhereDocument: map[string]interface{}{"$not_contains": "Kubernetes"},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.QueryText = "error handling and retries"
+			tt.opts.NResults = 5
+			docs, err := store.Query(ctx, tt.opts)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			var ids []string
+			for _, doc := range docs {
+				ids = append(ids, doc.ID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("Query() = %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("Query() = %v, want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+
+	_, err := store.Query(ctx, chromadb.QueryOptions{QueryText: "Go", NResults: 5, Where: map[string]interface{}{"category": map[string]interface{}{"$like": "cv"}}})
+	if err == nil {
+		t.Error("Query() accepted an unsupported operator")
+	}
+}
+
+func TestEmbeddedStoreDistanceMatchesChromaL2(t *testing.T) {
+	embedder := chromadb.NewHashingEmbedder(0)
+	store := newTestStore(t, writeGuidelines(t), embedder, "")
+	ctx := context.Background()
+
+	query := "backend skills such as Go"
+	docs, err := store.Query(ctx, chromadb.QueryOptions{QueryText: query, NResults: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ChromaDB's "l2" space reports the squared Euclidean distance
+	q, _ := embedder.EmbedQuery(ctx, query)
+	for _, doc := range docs {
+		vectors, _ := embedder.EmbedDocuments(ctx, []string{doc.Content})
+		var l2 float64
+		for i := range q {
+			d := q[i] - vectors[0][i]
+			l2 += d * d
+		}
+		if math.Abs(doc.Distance-l2) > 1e-9 {
+			t.Errorf("distance of %s = %v, ChromaDB l2 = %v", doc.ID, doc.Distance, l2)
+		}
+	}
+
+	// A cutoff drops matches that are further away
+	cutoff := (docs[0].Distance + docs[1].Distance) / 2
+	near, err := store.Query(ctx, chromadb.QueryOptions{QueryText: query, NResults: 5, MaxDistance: cutoff})
+	if err != nil || len(near) != 1 || near[0].ID != docs[0].ID {
+		t.Errorf("Query() with MaxDistance = %+v, %v; want only %s", near, err, docs[0].ID)
+	}
+}
+
+func TestEmbeddedStoreInclude(t *testing.T) {
+	store := newTestStore(t, writeGuidelines(t), chromadb.NewHashingEmbedder(0), "")
+
+	docs, err := store.Query(context.Background(), chromadb.QueryOptions{
+		QueryText: "Go",
+		NResults:  1,
+		Include:   []string{chromadb.IncludeMetadatas},
+	})
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("Query() = %+v, %v", docs, err)
+	}
+	if docs[0].Content != "" || docs[0].Distance != 0 || docs[0].Metadata == nil || docs[0].ID == "" {
+		t.Errorf("Query() = %+v, want only the ID and metadata", docs[0])
+	}
+}
+
+func TestEmbeddedStoreCache(t *testing.T) {
+	dir := writeGuidelines(t)
+	cachePath := filepath.Join(t.TempDir(), "embeddings.json")
+
+	// The first load embeds every chunk and saves them
+	embedder := &countingEmbedder{Embedder: chromadb.NewHashingEmbedder(0)}
+	newTestStore(t, dir, embedder, cachePath)
+	if embedder.embedded != 4 {
+		t.Errorf("first load embedded %d chunks, want 4", embedder.embedded)
+	}
+	if got := len(readCache(t, cachePath).Vectors); got != 4 {
+		t.Errorf("cache holds %d vectors, want 4", got)
+	}
+
+	// Unchanged guidelines are served from the cache
+	embedder = &countingEmbedder{Embedder: chromadb.NewHashingEmbedder(0)}
+	newTestStore(t, dir, embedder, cachePath)
+	if embedder.embedded != 0 {
+		t.Errorf("reload embedded %d chunks, want 0", embedder.embedded)
+	}
+
+	// A changed file is embedded again and its old chunk is pruned
+	writeFile(t, filepath.Join(dir, "cv_evaluation.txt"), "CV evaluation. Score cloud experience.")
+	embedder = &countingEmbedder{Embedder: chromadb.NewHashingEmbedder(0)}
+	store := newTestStore(t, dir, embedder, cachePath)
+	if embedder.embedded != 1 {
+		t.Errorf("reload after a change embedded %d chunks, want 1", embedder.embedded)
+	}
+	cache := readCache(t, cachePath)
+	if len(cache.Vectors) != 4 {
+		t.Errorf("cache holds %d vectors after pruning, want 4", len(cache.Vectors))
+	}
+	if _, ok := cache.Vectors[textKey("CV evaluation. Score cloud experience.")]; !ok {
+		t.Error("cache is missing the changed chunk")
+	}
+	docs, _ := store.Query(context.Background(), chromadb.QueryOptions{QueryText: "cloud", NResults: 1})
+	if len(docs) != 1 || docs[0].Content != "CV evaluation. Score cloud experience." {
+		t.Errorf("Query() = %+v, want the changed chunk", docs)
+	}
+
+	// A cache built by another embedder is ignored and replaced
+	embedder = &countingEmbedder{Embedder: chromadb.NewHashingEmbedder(64)}
+	newTestStore(t, dir, embedder, cachePath)
+	if embedder.embedded != 4 {
+		t.Errorf("load with another embedder embedded %d chunks, want 4", embedder.embedded)
+	}
+	if got := readCache(t, cachePath).Embedder; got != embedder.Identity() {
+		t.Errorf("cache embedder = %s, want %s", got, embedder.Identity())
+	}
+
+	// An unreadable cache is rebuilt rather than failing the store
+	writeFile(t, cachePath, "{not json")
+	embedder = &countingEmbedder{Embedder: chromadb.NewHashingEmbedder(64)}
+	newTestStore(t, dir, embedder, cachePath)
+	if embedder.embedded != 4 {
+		t.Errorf("load with a corrupt cache embedded %d chunks, want 4", embedder.embedded)
+	}
+	if _, err := os.Stat(cachePath + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary cache file left behind: %v", err)
+	}
+}
+
+func readCache(t *testing.T, path string) embeddingCache {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cache embeddingCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
@@ -0,0 +1,172 @@
+package vectorstore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// matchWhere evaluates a ChromaDB metadata filter against a document's metadata. It supports
+// equality, $eq, $ne, $gt, $gte, $lt, $lte, $in, $nin, $and and $or.
+func matchWhere(metadata, where map[string]interface{}) (bool, error) {
+	for key, cond := range where {
+		var ok bool
+		var err error
+
+		switch key {
+		case "$and", "$or":
+			ok, err = matchLogical(key, cond, func(clause map[string]interface{}) (bool, error) {
+				return matchWhere(metadata, clause)
+			})
+		default:
+			ok, err = matchField(metadata[key], cond)
+		}
+
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchWhereDocument evaluates a ChromaDB document filter: $contains, $not_contains, $and and $or
+func matchWhereDocument(content string, where map[string]interface{}) (bool, error) {
+	for key, cond := range where {
+		var ok bool
+		var err error
+
+		switch key {
+		case "$and", "$or":
+			ok, err = matchLogical(key, cond, func(clause map[string]interface{}) (bool, error) {
+				return matchWhereDocument(content, clause)
+			})
+		case "$contains", "$not_contains":
+			text, isString := cond.(string)
+			if !isString {
+				return false, fmt.Errorf("%s expects a string", key)
+			}
+			ok = strings.Contains(content, text) == (key == "$contains")
+		default:
+			return false, fmt.Errorf("unsupported where_document operator %q", key)
+		}
+
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchLogical applies match to each clause of an $and or $or
+func matchLogical(op string, cond interface{}, match func(map[string]interface{}) (bool, error)) (bool, error) {
+	var clauses []map[string]interface{}
+	switch v := cond.(type) {
+	case []map[string]interface{}:
+		clauses = v
+	case []interface{}:
+		for _, c := range v {
+			clause, ok := c.(map[string]interface{})
+			if !ok {
+				return false, fmt.Errorf("%s expects a list of filters", op)
+			}
+			clauses = append(clauses, clause)
+		}
+	default:
+		return false, fmt.Errorf("%s expects a list of filters", op)
+	}
+
+	for _, clause := range clauses {
+		ok, err := match(clause)
+		if err != nil {
+			return false, err
+		}
+		if op == "$or" && ok {
+			return true, nil
+		}
+		if op == "$and" && !ok {
+			return false, nil
+		}
+	}
+	return op == "$and", nil
+}
+
+// matchField evaluates a condition on a single metadata value; a bare value means $eq
+func matchField(value, cond interface{}) (bool, error) {
+	ops, isMap := cond.(map[string]interface{})
+	if !isMap {
+		return equal(value, cond), nil
+	}
+
+	for op, operand := range ops {
+		var ok bool
+		switch op {
+		case "$eq":
+			ok = equal(value, operand)
+		case "$ne":
+			ok = !equal(value, operand)
+		case "$gt", "$gte", "$lt", "$lte":
+			a, aOK := toFloat(value)
+			b, bOK := toFloat(operand)
+			if !bOK {
+				return false, fmt.Errorf("%s expects a number", op)
+			}
+			ok = aOK && ((op == "$gt" && a > b) || (op == "$gte" && a >= b) || (op == "$lt" && a < b) || (op == "$lte" && a <= b))
+		case "$in", "$nin":
+			list, err := toList(operand)
+			if err != nil {
+				return false, fmt.Errorf("%s %w", op, err)
+			}
+			found := false
+			for _, item := range list {
+				if equal(value, item) {
+					found = true
+					break
+				}
+			}
+			ok = found == (op == "$in")
+		default:
+			return false, fmt.Errorf("unsupported where operator %q", op)
+		}
+
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// equal compares metadata values, treating all numeric types alike
+func equal(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+	return a == b
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func toList(v interface{}) ([]interface{}, error) {
+	switch list := v.(type) {
+	case []interface{}:
+		return list, nil
+	case []string:
+		items := make([]interface{}, len(list))
+		for i, s := range list {
+			items[i] = s
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("expects a list")
+}
@@ -0,0 +1,124 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// filter decodes a filter from JSON, as it arrives from API callers and the kb CLI
+func filter(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var f map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &f); err != nil {
+		t.Fatalf("invalid filter %s: %v", s, err)
+	}
+	return f
+}
+
+func TestMatchWhere(t *testing.T) {
+	metadata := map[string]interface{}{
+		"category":     "cv_evaluation",
+		"source":       "cv_evaluation.txt",
+		"chunk_offset": 800, // int, as built by GuidelineDocuments
+	}
+
+	tests := []struct {
+		where string
+		want  bool
+	}{
+		{`{}`, true},
+		{`{"category": "cv_evaluation"}`, true},
+		{`{"category": "evaluation_criteria"}`, false},
+		{`{"missing": "x"}`, false},
+		{`{"category": {"$eq": "cv_evaluation"}}`, true},
+		{`{"category": {"$ne": "cv_evaluation"}}`, false},
+		{`{"missing": {"$ne": "x"}}`, true},
+		{`{"chunk_offset": 800}`, true}, // JSON numbers are float64
+		{`{"chunk_offset": {"$gt": 799}}`, true},
+		{`{"chunk_offset": {"$gte": 800, "$lt": 801}}`, true},
+		{`{"chunk_offset": {"$lte": 799.5}}`, false},
+		{`{"source": {"$gt": 1}}`, false}, // strings never compare with numbers
+		{`{"category": {"$in": ["cv_evaluation", "evaluation_criteria"]}}`, true},
+		{`{"category": {"$nin": ["cv_evaluation"]}}`, false},
+		{`{"chunk_offset": {"$in": [0, 800]}}`, true},
+		{`{"category": "cv_evaluation", "chunk_offset": 0}`, false},
+		{`{"$and": [{"category": "cv_evaluation"}, {"chunk_offset": {"$gte": 800}}]}`, true},
+		{`{"$and": [{"category": "cv_evaluation"}, {"chunk_offset": 0}]}`, false},
+		{`{"$or": [{"category": "evaluation_criteria"}, {"source": "cv_evaluation.txt"}]}`, true},
+		{`{"$or": [{"category": "evaluation_criteria"}, {"chunk_offset": 0}]}`, false},
+		{`{"$or": [{"$and": [{"category": "cv_evaluation"}, {"chunk_offset": 800}]}, {"category": "x"}]}`, true},
+	}
+
+	for _, tt := range tests {
+		got, err := matchWhere(metadata, filter(t, tt.where))
+		if err != nil {
+			t.Errorf("matchWhere(%s) error = %v", tt.where, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("matchWhere(%s) = %v, want %v", tt.where, got, tt.want)
+		}
+	}
+}
+
+func TestMatchWhereGoValues(t *testing.T) {
+	// Filters built in Go, as the pipeline does, use native slices rather than []interface{}
+	metadata := map[string]interface{}{"category": "cv_evaluation"}
+	where := map[string]interface{}{
+		"$and": []map[string]interface{}{
+			{"category": map[string]interface{}{"$in": []string{"cv_evaluation"}}},
+		},
+	}
+	if ok, err := matchWhere(metadata, where); err != nil || !ok {
+		t.Errorf("matchWhere() = %v, %v; want true", ok, err)
+	}
+}
+
+func TestMatchWhereErrors(t *testing.T) {
+	metadata := map[string]interface{}{"category": "cv_evaluation"}
+	for _, where := range []string{
+		`{"category": {"$like": "cv%"}}`,
+		`{"category": {"$gt": "a"}}`,
+		`{"category": {"$in": "cv_evaluation"}}`,
+		`{"$and": {"category": "cv_evaluation"}}`,
+		`{"$or": ["cv_evaluation"]}`,
+	} {
+		if _, err := matchWhere(metadata, filter(t, where)); err == nil {
+			t.Errorf("matchWhere(%s) returned no error", where)
+		}
+	}
+}
+
+func TestMatchWhereDocument(t *testing.T) {
+	content := "Score technical skills such as Go and PostgreSQL"
+
+	tests := []struct {
+		where string
+		want  bool
+	}{
+		{`{}`, true},
+		{`{"$contains": "PostgreSQL"}`, true},
+		{`{"$contains": "postgresql"}`, false}, // case-sensitive, like ChromaDB
+		{`{"$not_contains": "Kubernetes"}`, true},
+		{`{"$not_contains": "Go"}`, false},
+		{`{"$and": [{"$contains": "Go"}, {"$contains": "Score"}]}`, true},
+		{`{"$and": [{"$contains": "Go"}, {"$contains": "Rust"}]}`, false},
+		{`{"$or": [{"$contains": "Rust"}, {"$contains": "Go"}]}`, true},
+	}
+	for _, tt := range tests {
+		got, err := matchWhereDocument(content, filter(t, tt.where))
+		if err != nil {
+			t.Errorf("matchWhereDocument(%s) error = %v", tt.where, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("matchWhereDocument(%s) = %v, want %v", tt.where, got, tt.want)
+		}
+	}
+
+	for _, where := range []string{`{"$regex": "Go"}`, `{"$contains": 1}`} {
+		if _, err := matchWhereDocument(content, filter(t, where)); err == nil {
+			t.Errorf("matchWhereDocument(%s) returned no error", where)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package vectorstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"aicvevaluator/internal/chromadb"
+	"aicvevaluator/internal/util"
+)
+
+// GuidelineType marks knowledge base documents built from guideline files
+const GuidelineType = "evaluation_guideline"
+
+// Default chunking settings for guideline files
+const (
+	DefaultChunkSize    = 800
+	DefaultChunkOverlap = 100
+)
+
+// FileCategories maps guideline file names to the category the evaluation pipeline filters
+// on, for files that do not declare one in front matter
+var FileCategories = map[string]string{
+	"cv_evaluation":      "cv_evaluation",
+	"project_evaluation": "evaluation_criteria",
+}
+
+// ErrUncategorizedGuideline is returned for guideline files whose category is unknown
+var ErrUncategorizedGuideline = errors.New("guideline file has no category")
+
+// frontMatterDelimiter opens and closes the optional front matter of a guideline file, e.g.
+//
+//	---
+//	category: evaluation_criteria
+//	---
+const frontMatterDelimiter = "---"
+
+// GuidelineCategory returns the category of a guideline file and its content without front
+// matter. The category is read from front matter, falling back to FileCategories.
+func GuidelineCategory(source string, content []byte) (string, []byte, error) {
+	category, body, err := parseFrontMatter(content)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", source, err)
+	}
+	if category == "" {
+		category = FileCategories[strings.TrimSuffix(source, filepath.Ext(source))]
+	}
+	if category == "" {
+		return "", nil, fmt.Errorf("%w: %s; add a category front matter line or an entry in FileCategories", ErrUncategorizedGuideline, source)
+	}
+	return category, body, nil
+}
+
+// parseFrontMatter returns the category declared in content's front matter, if any, and the content after it
+func parseFrontMatter(content []byte) (string, []byte, error) {
+	text := strings.TrimPrefix(string(content), "\ufeff")
+	first, rest, _ := strings.Cut(text, "\n")
+	if strings.TrimSpace(first) != frontMatterDelimiter {
+		return "", content, nil
+	}
+
+	var category string
+	for {
+		var line string
+		var ok bool
+		line, rest, ok = strings.Cut(rest, "\n")
+		if strings.TrimSpace(line) == frontMatterDelimiter {
+			return category, []byte(rest), nil
+		}
+		if !ok {
+			return "", nil, errors.New("front matter is not closed")
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return "", nil, fmt.Errorf("invalid front matter line %q", line)
+		}
+		if strings.TrimSpace(key) == "category" {
+			category = strings.TrimSpace(value)
+		}
+	}
+}
+
+// IsGuidelineFile reports whether a file name is a guideline document
+func IsGuidelineFile(name string) bool {
+	return strings.HasSuffix(name, ".txt")
+}
+
+// GuidelineDocuments splits a guideline file into documents with stable IDs derived from
+// the file name and chunk offset. hash is recorded so unchanged files can be detected.
+// It returns ErrUncategorizedGuideline if the file's category is unknown.
+func GuidelineDocuments(source string, content []byte, chunker *util.Chunker, hash string) ([]chromadb.Document, error) {
+	category, body, err := GuidelineCategory(source, content)
+	if err != nil {
+		return nil, err
+	}
+	docID := strings.TrimSuffix(source, filepath.Ext(source))
+
+	var docs []chromadb.Document
+	for _, chunk := range chunker.Split(string(body)) {
+		metadata := map[string]interface{}{
+			"source":       source,
+			"type":         GuidelineType,
+			"category":     category,
+			"content_hash": hash,
+			"chunk_offset": chunk.Offset,
+		}
+		if chunk.Heading != "" {
+			metadata["heading"] = chunk.Heading
+		}
+
+		docs = append(docs, chromadb.Document{
+			ID:       fmt.Sprintf("%s:%d", docID, chunk.Offset),
+			Content:  chunk.Text,
+			Metadata: metadata,
+		})
+	}
+	return docs, nil
+}
+
+// ContentHash identifies a file's content together with the chunking settings that produced its chunks
+func ContentHash(content []byte, chunkSize, chunkOverlap int) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d:%d:", chunkSize, chunkOverlap)
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
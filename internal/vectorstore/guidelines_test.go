@@ -0,0 +1,64 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"aicvevaluator/internal/chromadb"
+	"aicvevaluator/internal/util"
+)
+
+func TestGuidelineCategory(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		content      string
+		wantCategory string
+		wantBody     string
+		wantErr      bool
+	}{
+		{"mapped file name", "cv_evaluation.txt", "Score the CV.", "cv_evaluation", "Score the CV.", false},
+		{"front matter", "scoring.txt", "---\ncategory: scoring\n---\nUse a 1-5 scale.", "scoring", "Use a 1-5 scale.", false},
+		{"front matter overrides the file name", "cv_evaluation.txt", "---\r\ncategory: evaluation_criteria \r\n---\r\nScore the CV.", "evaluation_criteria", "Score the CV.", false},
+		{"other front matter keys", "scoring.txt", "---\ntitle: Scoring\ncategory: scoring\n---\nUse a 1-5 scale.", "scoring", "Use a 1-5 scale.", false},
+		{"unmapped file", "scoring.txt", "Use a 1-5 scale.", "", "", true},
+		{"front matter without a category", "scoring.txt", "---\ntitle: Scoring\n---\nUse a 1-5 scale.", "", "", true},
+		{"unclosed front matter", "cv_evaluation.txt", "---\ncategory: scoring\nUse a 1-5 scale.", "", "", true},
+		{"invalid front matter line", "cv_evaluation.txt", "---\ncategory scoring\n---\n", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category, body, err := GuidelineCategory(tt.source, []byte(tt.content))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("GuidelineCategory() = %q, want an error", category)
+				}
+				return
+			}
+			if err != nil || category != tt.wantCategory || strings.TrimSpace(string(body)) != tt.wantBody {
+				t.Errorf("GuidelineCategory() = %q, %q, %v; want %q, %q", category, body, err, tt.wantCategory, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestGuidelineDocumentsUncategorized(t *testing.T) {
+	chunker, err := util.NewChunker(DefaultChunkSize, DefaultChunkOverlap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GuidelineDocuments("scoring.txt", []byte("Use a 1-5 scale."), chunker, ""); !errors.Is(err, ErrUncategorizedGuideline) {
+		t.Errorf("GuidelineDocuments() error = %v, want ErrUncategorizedGuideline", err)
+	}
+
+	// The embedded store refuses to start rather than serve guidelines no query can reach
+	dir := writeGuidelines(t)
+	writeFile(t, filepath.Join(dir, "scoring.txt"), "Use a 1-5 scale.")
+	if _, err := NewEmbeddedStore(context.Background(), chromadb.NewHashingEmbedder(0), EmbeddedConfig{Dir: dir}); !errors.Is(err, ErrUncategorizedGuideline) {
+		t.Errorf("NewEmbeddedStore() error = %v, want ErrUncategorizedGuideline", err)
+	}
+}
@@ -7,10 +7,11 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"aicvevaluator/internal/chromadb"
 	"aicvevaluator/internal/config"
+	"aicvevaluator/internal/util"
+	"aicvevaluator/internal/vectorstore"
 
 	"github.com/joho/godotenv"
 )
@@ -18,8 +19,16 @@ import (
 func main() {
 	// Parse command line flags
 	dataDir := flag.String("dir", "data/evaluation_guidelines", "Directory containing evaluation guideline documents")
+	chunkSize := flag.Int("chunk-size", vectorstore.DefaultChunkSize, "Maximum chunk size in bytes")
+	chunkOverlap := flag.Int("chunk-overlap", vectorstore.DefaultChunkOverlap, "Bytes repeated from the previous chunk when a section is split")
+	collectionFlag := flag.String("collection", "", "Collection to seed, e.g. one per hiring track (defaults to CHROMADB_COLLECTION)")
 	flag.Parse()
 
+	chunker, err := util.NewChunker(*chunkSize, *chunkOverlap)
+	if err != nil {
+		log.Fatalf("Invalid chunking settings: %v", err)
+	}
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found. Using environment variables.")
@@ -31,18 +40,55 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	collection := cfg.ChromaDB.Collection
+	if *collectionFlag != "" {
+		collection = *collectionFlag
+	}
+
 	// Create ChromaDB client
-	client, err := chromadb.NewClient(cfg.ChromaDBURL)
+	ctx := context.Background()
+	embedder, err := chromadb.NewEmbedder(ctx, chromadb.EmbedderConfig{
+		Provider: cfg.Embedding.Provider,
+		Model:    cfg.Embedding.Model,
+		APIKey:   cfg.Embedding.APIKey,
+		BaseURL:  cfg.Embedding.BaseURL,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+	defer embedder.Close()
+
+	client, err := chromadb.NewClient(cfg.ChromaDBURL, embedder,
+		chromadb.WithTenant(cfg.ChromaDB.Tenant),
+		chromadb.WithDatabase(cfg.ChromaDB.Database),
+		chromadb.WithCollection(collection),
+		chromadb.WithAuthToken(cfg.ChromaDB.AuthHeader, cfg.ChromaDB.AuthToken),
+		chromadb.WithTimeout(cfg.ChromaDB.Timeout),
+	)
 	if err != nil {
 		log.Fatalf("Failed to create ChromaDB client: %v", err)
 	}
 
 	// Initialize collection (this will create it if it doesn't exist)
-	ctx := context.Background()
 	if err := client.InitializeCollection(ctx); err != nil {
 		log.Fatalf("Failed to initialize ChromaDB collection: %v", err)
 	}
-	fmt.Println("✅ ChromaDB collection initialized")
+	fmt.Printf("✅ ChromaDB collection initialized (embedder: %s)\n", client.EmbedderIdentity())
+
+	// Index what is already stored so unchanged files can be skipped and stale chunks removed
+	existing, err := client.Get(ctx, chromadb.GetOptions{
+		Where:   map[string]interface{}{"type": vectorstore.GuidelineType},
+		Include: []string{chromadb.IncludeMetadatas},
+	})
+	if err != nil {
+		log.Fatalf("Failed to list existing guideline chunks: %v", err)
+	}
+
+	storedChunks := make(map[string][]chromadb.Document) // source file name -> chunks
+	for _, doc := range existing {
+		source, _ := doc.Metadata["source"].(string)
+		storedChunks[source] = append(storedChunks[source], doc)
+	}
 
 	// Read files from the data directory
 	files, err := os.ReadDir(*dataDir)
@@ -50,37 +96,86 @@ func main() {
 		log.Fatalf("Failed to read data directory: %v", err)
 	}
 
+	keep := make(map[string]bool)
+
 	// Process each file
 	for _, file := range files {
-		if file.IsDir() || !strings.HasSuffix(file.Name(), ".txt") {
+		if file.IsDir() || !vectorstore.IsGuidelineFile(file.Name()) {
 			continue // Skip directories and non-text files
 		}
 
-		filePath := filepath.Join(*dataDir, file.Name())
+		source := file.Name()
+		stored := storedChunks[source]
+		filePath := filepath.Join(*dataDir, source)
 		fmt.Printf("Processing %s...\n", filePath)
 
 		// Read file content
 		content, err := os.ReadFile(filePath)
 		if err != nil {
 			log.Printf("Warning: Failed to read file %s: %v", filePath, err)
+			keepAll(keep, stored) // leave the previous version in place
 			continue
 		}
 
-		// Add document to ChromaDB
-		docID := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
-		metadata := map[string]interface{}{
-			"source": filePath,
-			"type":   "evaluation_guideline",
+		hash := vectorstore.ContentHash(content, *chunkSize, *chunkOverlap)
+		if isUnchanged(stored, hash) {
+			keepAll(keep, stored)
+			fmt.Printf("⏭️  %s is unchanged, skipping\n", source)
+			continue
 		}
 
-		err = client.AddDocument(ctx, docID, string(content), metadata)
+		// Split the file and upsert its chunks
+		docs, err := vectorstore.GuidelineDocuments(source, content, chunker, hash)
 		if err != nil {
-			log.Printf("Warning: Failed to add document %s to ChromaDB: %v", docID, err)
+			log.Printf("Warning: Skipping %s: %v", filePath, err)
+			keepAll(keep, stored)
 			continue
 		}
 
-		fmt.Printf("✅ Added document %s to ChromaDB\n", docID)
+		if err := client.Upsert(ctx, docs); err != nil {
+			log.Printf("Warning: Failed to upsert chunks of %s to ChromaDB: %v", source, err)
+			keepAll(keep, stored)
+			continue
+		}
+
+		for _, doc := range docs {
+			keep[doc.ID] = true
+		}
+		fmt.Printf("✅ Upserted %d chunks from %s\n", len(docs), source)
+	}
+
+	// Remove chunks from deleted files and chunks a changed file no longer produces
+	var stale []string
+	for _, doc := range existing {
+		if !keep[doc.ID] {
+			stale = append(stale, doc.ID)
+		}
+	}
+	if len(stale) > 0 {
+		if err := client.Delete(ctx, chromadb.DeleteOptions{IDs: stale}); err != nil {
+			log.Fatalf("Failed to delete stale chunks: %v", err)
+		}
+		fmt.Printf("🗑️  Deleted %d stale chunks\n", len(stale))
 	}
 
 	fmt.Println("✅ Seeding completed successfully!")
 }
+
+// isUnchanged reports whether every stored chunk of a file was produced from content with this hash
+func isUnchanged(stored []chromadb.Document, hash string) bool {
+	if len(stored) == 0 {
+		return false
+	}
+	for _, doc := range stored {
+		if storedHash, _ := doc.Metadata["content_hash"].(string); storedHash != hash {
+			return false
+		}
+	}
+	return true
+}
+
+func keepAll(keep map[string]bool, docs []chromadb.Document) {
+	for _, doc := range docs {
+		keep[doc.ID] = true
+	}
+}